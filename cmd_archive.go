@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	statusArchivePage   = "archiving page"
+	statusUnarchivePage = "unarchiving page"
+)
+
+// archiveCommand marks a page as archived. Archived pages are still built and
+// published, so links to them keep working, but they are left out of the index
+// and the tag pages. -undo brings the page back into them
+// Example:
+//  > til archive 3
+func archiveCommand(args []string) {
+	flags := flag.NewFlagSet("archive", flag.ExitOnError)
+	undo := flags.Bool("undo", false, "unarchives the page")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		src.Defeat(errors.New(errNoPageRef))
+	}
+
+	pageSet := loadPages()
+	page := findListedPage(pageSet, flags.Arg(0))
+
+	if *undo {
+		src.Info(statusUnarchivePage)
+	} else {
+		src.Info(statusArchivePage)
+	}
+
+	page.Archived = !*undo
+	page.Save()
+
+	src.Progress(fmt.Sprintf("%s is %s", page.FilePath, page.PublishState()))
+
+	if !*undo {
+		reportInboundLinks(pageSet, page)
+	}
+
+	buildContent()
+}
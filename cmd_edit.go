@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	// maxEditCandidates caps how many matching pages the edit prompt offers
+	maxEditCandidates = 10
+
+	errEditChoice  = "'%s' is not one of the pages, expected a number from 1 to %d"
+	errNoEditMatch = "no page has a title like '%s'"
+	errNoEditQuery = "part of the title of the page to edit must be specified"
+
+	statusEditChoose = "pages like '%s'"
+	statusEditSaved  = "updated"
+	statusEditSame   = "no changes"
+)
+
+// editCommand opens an existing page in the editor, finding it by its title.
+// The title doesn't need to be exact, or complete. If more than one page
+// matches, it asks which one to open. If the page changed, its updated date
+// is set to now
+// Example:
+//  > til edit go testing
+func editCommand(args []string) {
+	query := strings.Join(args, " ")
+	if strings.TrimSpace(query) == "" {
+		src.Defeat(errors.New(errNoEditQuery))
+	}
+
+	page := chooseEditPage(pages.FuzzyFind(loadPages(), query), query)
+
+	editPage(page)
+}
+
+// chooseEditPage picks the page to edit from the pages that matched the
+// query, best match first. The user is only asked to choose if no page's
+// title is exactly the query
+func chooseEditPage(candidates []*pages.Page, query string) *pages.Page {
+	switch {
+	case len(candidates) == 0:
+		src.Defeat(fmt.Errorf(errNoEditMatch, query))
+	case len(candidates) == 1, strings.EqualFold(candidates[0].Title, strings.TrimSpace(query)):
+		return candidates[0]
+	}
+
+	if len(candidates) > maxEditCandidates {
+		candidates = candidates[:maxEditCandidates]
+	}
+
+	src.Info(fmt.Sprintf(statusEditChoose, query))
+
+	for i, page := range candidates {
+		src.Progress(fmt.Sprintf("%2d %s %s", i+1, page.PrettyDate(), page.Title))
+	}
+
+	answer := prompt(fmt.Sprintf("which page? [1-%d]", len(candidates)))
+
+	choice, err := strconv.Atoi(answer)
+	if err != nil || choice < 1 || choice > len(candidates) {
+		src.Defeat(fmt.Errorf(errEditChoice, answer, len(candidates)))
+	}
+
+	return candidates[choice-1]
+}
+
+// editPage opens the page in the editor, then sets its updated date if the
+// file is different afterwards. Editors that hand the file off and return
+// straight away (like 'open') leave it unchanged as far as til can tell
+func editPage(page *pages.Page) {
+	before, err := ioutil.ReadFile(page.FilePath)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	err = page.Open(defaultEditor)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	after, err := ioutil.ReadFile(page.FilePath)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	if bytes.Equal(before, after) {
+		src.Info(fmt.Sprintf("%s %s", statusEditSame, page.FilePath))
+		return
+	}
+
+	// Re-read the page, the edits may have changed the front-matter
+	edited, err := pages.Load(page.FilePath)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	edited.SetUpdated(time.Now())
+	edited.Save()
+
+	src.Info(fmt.Sprintf("%s %s", statusEditSaved, page.FilePath))
+}
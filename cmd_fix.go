@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	errFixFailed = "%d page(s) could not be repaired and need fixing by hand"
+
+	statusFix = "checking page front-matter"
+)
+
+// fixCommand finds the pages whose front-matter can't be parsed and rewrites
+// them with safely-serialised front-matter, as best it can
+// Example:
+//  > til fix -dry-run
+func fixCommand(args []string) {
+	flags := flag.NewFlagSet("fix", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "reports the broken pages without rewriting them")
+	_ = flags.Parse(args)
+
+	rejectArgs(flags)
+
+	src.Info(statusFix)
+
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	broken := 0
+	failed := 0
+
+	for _, filePath := range pageFilePaths(tDir) {
+		_, err := pages.Load(filePath)
+		if err == nil {
+			continue
+		}
+
+		broken++
+		src.Progress(src.Red(err.Error()))
+
+		page, err := pages.Repair(filePath)
+		if err != nil {
+			failed++
+			src.Progress(fmt.Sprintf("could not repair: %s", err.Error()))
+			continue
+		}
+
+		if *dryRun {
+			src.Progress("can be repaired")
+			continue
+		}
+
+		page.Save()
+		src.Progress(fmt.Sprintf("repaired %s", filePath))
+	}
+
+	if broken == 0 {
+		src.Progress("all pages are fine")
+	}
+
+	if failed > 0 {
+		src.Defeat(fmt.Errorf(errFixFailed, failed))
+	}
+}
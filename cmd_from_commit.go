@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/repo"
+	"github.com/senorprogrammer/til/src"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	hunksAll  = "all"
+	hunksNone = "none"
+
+	errFromCommitArgs = "usage: til from-commit [-hunks all|none|1,3-4] [-tags <tags>] <repo-path> [rev]"
+	errHunkChoice     = "'%s' is not a choice of hunks, expected 'all', 'none', or numbers from 1 to %d like 1,3-4"
+
+	statusCommitHunks = "hunks in %.7s"
+)
+
+// scpURLRegex matches scp-style remote URLs, ie: git@github.com:user/repo.git,
+// capturing the user and the rest of the URL
+var scpURLRegex = regexp.MustCompile(`^([^@/:]+)@([^/:]+:.*)$`)
+
+// fromCommitCommand creates a page from a commit in any local git repository.
+// The commit's subject becomes the title and the rest of its message the
+// body, followed by the diff stat and the chosen hunks of the diff. The page
+// records the repository and the commit's hash in its front-matter, and is
+// opened in the editor. Without -hunks, it asks which hunks to include
+// Example:
+//  > til from-commit -hunks 1,3 ~/src/wtf HEAD~2
+func fromCommitCommand(args []string) {
+	flags := flag.NewFlagSet("from-commit", flag.ExitOnError)
+	hunkChoice := flags.String("hunks", "", "includes these hunks: 'all', 'none', or numbers like 1,3-4")
+	tagList := flags.String("tags", "", "sets the page's tags, separated by commas")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 || flags.NArg() > 2 {
+		src.Defeat(errors.New(errFromCommitArgs))
+	}
+
+	rev := "HEAD"
+	if flags.NArg() == 2 {
+		rev = flags.Arg(1)
+	}
+
+	repoPath, err := src.ExpandPath(flags.Arg(0))
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	r, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		src.Defeat(fmt.Errorf("%s: %s", repoPath, err))
+	}
+
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		src.Defeat(fmt.Errorf("%s: %s", rev, err))
+	}
+
+	c, err := r.CommitObject(*hash)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	patch, err := repo.CommitPatch(c)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	hunks, err := repo.Hunks(patch)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	if *hunkChoice == "" && len(hunks) > 0 {
+		*hunkChoice = askForHunks(hunks, c.Hash.String())
+	}
+
+	chosen, err := chooseHunks(hunks, *hunkChoice)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	subject, body := splitCommitMessage(c.Message)
+	if subject == "" {
+		subject = fmt.Sprintf("Commit %.7s", c.Hash.String())
+	}
+
+	src.BuildTargetDirectory()
+
+	createNewPage(pages.Titles.Case(subject), &newPageOptions{
+		body: commitPageBody(body, patch.Stats().String(), chosen),
+		frontMatter: yaml.MapSlice{
+			{Key: "commit", Value: yaml.MapSlice{
+				{Key: "repo", Value: repoReference(r, repoPath)},
+				{Key: "hash", Value: c.Hash.String()},
+			}},
+		},
+		openEditor: true,
+		tagNames:   pages.ParseTagList(*tagList),
+	})
+}
+
+// askForHunks lists the commit's hunks and asks which of them to include
+func askForHunks(hunks []*repo.Hunk, hash string) string {
+	src.Info(fmt.Sprintf(statusCommitHunks, hash))
+
+	for i, hunk := range hunks {
+		src.Progress(fmt.Sprintf("%2d %s %s", i+1, hunk.Path, hunk.Header()))
+	}
+
+	answer := prompt(fmt.Sprintf("which hunks? [%s, %s, or numbers like 1,3-4] (%s)", hunksAll, hunksNone, hunksAll))
+	if answer == "" {
+		return hunksAll
+	}
+
+	return answer
+}
+
+// chooseHunks returns the hunks the choice picks out: 'all' of them, 'none'
+// of them, or the numbered ones (counting from 1), ie: "1,3-4"
+func chooseHunks(hunks []*repo.Hunk, choice string) ([]*repo.Hunk, error) {
+	switch strings.TrimSpace(choice) {
+	case "", hunksAll:
+		return hunks, nil
+	case hunksNone:
+		return []*repo.Hunk{}, nil
+	}
+
+	chosen := []*repo.Hunk{}
+	badChoice := fmt.Errorf(errHunkChoice, choice, len(hunks))
+
+	for _, part := range strings.Split(choice, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, badChoice
+		}
+
+		to := from
+		if len(bounds) == 2 {
+			to, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, badChoice
+			}
+		}
+
+		if from < 1 || to > len(hunks) || from > to {
+			return nil, badChoice
+		}
+
+		chosen = append(chosen, hunks[from-1:to]...)
+	}
+
+	return chosen, nil
+}
+
+// commitPageBody returns the body of a page made from a commit: the rest of
+// the commit's message, its diff stat, and the chosen hunks of its diff
+func commitPageBody(message string, stat string, hunks []*repo.Hunk) string {
+	body := ""
+
+	if message != "" {
+		body += message + "\n\n"
+	}
+
+	body += pages.CodeBlock("", stat)
+
+	for _, hunk := range hunks {
+		body += fmt.Sprintf("\n`%s`\n\n%s", hunk.Path, pages.CodeBlock("diff", hunk.Text))
+	}
+
+	return body
+}
+
+// repoReference returns how the page refers to the repository: the URL of
+// its remote, if it has one, otherwise its absolute path
+func repoReference(r *git.Repository, repoPath string) string {
+	remote, err := r.Remote(repo.RemoteName(r))
+	if err == nil && len(remote.Config().URLs) > 0 {
+		if ref, ok := publicRemoteURL(remote.Config().URLs[0]); ok {
+			return ref
+		}
+	}
+
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return repoPath
+	}
+
+	return absPath
+}
+
+// publicRemoteURL returns the remote URL without the user name, password, or
+// token that may be in it, so that they don't end up published on a page.
+// scp-style URLs (user@host:path) lose their user the same way. It returns
+// false if the URL can't be parsed, and so can't be cleaned up
+func publicRemoteURL(remoteURL string) (string, bool) {
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return "", false
+		}
+
+		u.User = nil
+
+		return u.String(), true
+	}
+
+	if match := scpURLRegex.FindStringSubmatch(remoteURL); match != nil {
+		return match[2], true
+	}
+
+	return remoteURL, true
+}
+
+// splitCommitMessage returns the first line of the commit message and the
+// rest of it, both trimmed
+func splitCommitMessage(message string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+
+	subject := strings.TrimSpace(parts[0])
+	if len(parts) == 1 {
+		return subject, ""
+	}
+
+	return subject, strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	errNoPageRef = "a page must be specified"
+)
+
+// historyCommand lists the commits that changed a page, newest first
+// Example:
+//  > til history 2020-05-07T13-13-08-zombies.md
+func historyCommand(args []string) {
+	if len(args) == 0 {
+		src.Defeat(errors.New(errNoPageRef))
+	}
+
+	page, err := pages.Find(loadPagesWithHistory(), args[0])
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Info(page.FilePath)
+
+	if len(page.Revisions) == 0 {
+		src.Progress("not committed yet")
+		return
+	}
+
+	for _, rev := range page.Revisions {
+		src.Progress(
+			fmt.Sprintf(
+				"%s %s %s %s",
+				rev.ShortHash(),
+				rev.When.Format("Jan 02, 2006 15:04"),
+				src.Blue(rev.Author),
+				rev.Subject(),
+			),
+		)
+	}
+}
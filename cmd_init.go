@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	gitConfig "github.com/go-git/go-git/v5/config"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	initCommitMsg = "initial commit"
+
+	// The GitHub Pages config that lives in /docs
+	pagesConfig = `theme: jekyll-theme-minimal
+`
+
+	// The .gitignore that lives in the root of the target
+	targetGitIgnore = `.DS_Store
+.trash/
+`
+
+	errInitExists = "%s is already a git repository"
+
+	statusRepoInit = "initialising target"
+)
+
+// initCommand turns a target directory defined in the config into a git
+// repository that's ready to publish: it creates the directories, writes a
+// starter index page, .gitignore, and GitHub Pages config, optionally adds a
+// remote, and makes the first commit
+// Example:
+//  > til init -remote git@github.com:you/til.git b
+func initCommand(args []string) {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	remoteURL := flags.String("remote", "", "the URL of the remote to push to")
+	_ = flags.Parse(args)
+
+	// The target key can be passed in either with -target or as the argument
+	if flags.NArg() > 0 {
+		targetDirFlag = flags.Arg(0)
+	}
+
+	root, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	if _, err := git.PlainOpen(root); err == nil {
+		src.Defeat(fmt.Errorf(errInitExists, root))
+	}
+
+	src.Info(statusRepoInit)
+
+	err = os.MkdirAll(tDir, os.ModePerm)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	r, err := git.PlainInit(root, false)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Progress(root)
+
+	writeFileIfMissing(filepath.Join(root, ".gitignore"), targetGitIgnore)
+	writeFileIfMissing(filepath.Join(tDir, "_config.yml"), pagesConfig)
+
+	buildContent()
+
+	if *remoteURL != "" {
+		_, err = r.CreateRemote(&gitConfig.RemoteConfig{
+			Name: "origin",
+			URLs: []string{*remoteURL},
+		})
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		src.Progress(fmt.Sprintf("added remote origin %s", *remoteURL))
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	_, err = w.Add(".")
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	obj := commit(r, initCommitMsg)
+
+	src.Info(fmt.Sprintf("committed with '%s' (%.7s)", obj.Message, obj.Hash.String()))
+}
+
+// writeFileIfMissing writes the content to the file unless the file already
+// exists, in which case it is left untouched
+func writeFileIfMissing(filePath string, content string) {
+	if _, err := os.Stat(filePath); err == nil {
+		return
+	}
+
+	err := ioutil.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Progress(filePath)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	errLintArgs     = "usage: til lint tags"
+	errLintProblems = "%d tag problem(s) found"
+
+	statusLintCase      = "tags that differ only in case"
+	statusLintNear      = "tags that look alike"
+	statusLintSingleton = "tags used only once"
+	statusLintUntagged  = "untagged pages"
+)
+
+// lintCommand checks the target for common problems. Tags are all it knows
+// about so far. Finding any problems is treated as a failure, so that it can
+// be used in scripts and hooks
+// Example:
+//  > til lint tags
+func lintCommand(args []string) {
+	if len(args) != 1 || args[0] != "tags" {
+		src.Defeat(errors.New(errLintArgs))
+	}
+
+	problems := lintTags(loadPages())
+	if problems > 0 {
+		src.Defeat(fmt.Errorf(errLintProblems, problems))
+	}
+}
+
+// lintTags reports the tag problems in the set of pages, and returns how many
+// there were. Tags used only once are reported, but aren't counted as problems
+func lintTags(pageSet []*pages.Page) int {
+	problems := 0
+
+	src.Info(statusLintUntagged)
+
+	untagged := pages.Untagged(pageSet)
+	for _, page := range untagged {
+		src.Progress(filepath.Base(page.FilePath))
+	}
+	problems += len(untagged)
+	reportNone(len(untagged))
+
+	src.Info(statusLintCase)
+
+	caseGroups := pages.CaseOnlyTags(pageSet)
+	for _, group := range caseGroups {
+		src.Progress(strings.Join(group, ", "))
+	}
+	problems += len(caseGroups)
+	reportNone(len(caseGroups))
+
+	tagMap := pages.NewTagMap(pageSet)
+
+	src.Info(statusLintNear)
+
+	pairs := pages.NearDuplicateTags(tagMap.SortedTagNames())
+	for _, pair := range pairs {
+		src.Progress(fmt.Sprintf("%s, %s", pair.A, pair.B))
+	}
+	problems += len(pairs)
+	reportNone(len(pairs))
+
+	src.Info(statusLintSingleton)
+
+	singletons := pages.SingletonTags(tagMap)
+	for _, tagName := range singletons {
+		src.Progress(tagName)
+	}
+	reportNone(len(singletons))
+
+	return problems
+}
+
+// reportNone says so when a check didn't find anything
+func reportNone(count int) {
+	if count == 0 {
+		src.Progress("none")
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	listDateFormat = "2006-01-02"
+
+	listFormatJSON  = "json"
+	listFormatPaths = "paths"
+	listFormatTable = "table"
+
+	listSortDate    = "date"
+	listSortTitle   = "title"
+	listSortUpdated = "updated"
+
+	errListDate   = "could not read the -%s date '%s', expected YYYY-MM-DD"
+	errListFormat = "unknown -format, expected 'table', 'paths', or 'json'"
+	errListSort   = "unknown -sort, expected 'date', 'title', or 'updated'"
+)
+
+// listedPage is a content page along with its position in the full,
+// unfiltered list of pages. That position can be passed to 'til show'
+type listedPage struct {
+	Index int
+	Page  *pages.Page
+}
+
+// pageFilter decides which pages 'til list' shows
+type pageFilter struct {
+	pendingOnly bool
+	since       time.Time
+	tagName     string
+	title       string
+	until       time.Time
+}
+
+// listCommand lists the pages in the target along with whether they are
+// published, drafts, scheduled, or unlisted. The pages can be filtered,
+// sorted, and written out as a table, as plain paths, or as JSON
+// Example:
+//  > til list -tag go -since 2020-01-01 -sort title -format paths
+func listCommand(args []string) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	pendingOnly := flags.Bool("drafts", false, "only lists drafts and pages scheduled for the future")
+	format := flags.String("format", listFormatTable, "writes the list as a 'table', as plain 'paths', or as 'json'")
+	reverse := flags.Bool("reverse", false, "reverses the sort order")
+	since := flags.String("since", "", "only lists pages created on or after this date (YYYY-MM-DD)")
+	sortBy := flags.String("sort", listSortDate, "sorts the pages by 'date' (newest first), 'title', or 'updated' (most recent first)")
+	tagName := flags.String("tag", "", "only lists pages with this tag, or one of its child tags")
+	title := flags.String("title", "", "only lists pages with this in their title")
+	until := flags.String("until", "", "only lists pages created on or before this date (YYYY-MM-DD)")
+	_ = flags.Parse(args)
+
+	filter := &pageFilter{
+		pendingOnly: *pendingOnly,
+		since:       parseListDate("since", *since),
+		tagName:     pages.Normaliser.Normalise(*tagName),
+		title:       strings.ToLower(*title),
+		until:       parseListDate("until", *until),
+	}
+
+	// The until date includes the whole of that day
+	if !filter.until.IsZero() {
+		filter.until = filter.until.AddDate(0, 0, 1)
+	}
+
+	// Only sorting by, or printing, when pages were updated needs their history
+	pageSet := loadPages()
+	if *sortBy == listSortUpdated || *format == listFormatJSON {
+		loadRevisions(pageSet)
+	}
+
+	listed := []*listedPage{}
+	for _, lPage := range indexedPages(pageSet) {
+		if filter.matches(lPage.Page) {
+			listed = append(listed, lPage)
+		}
+	}
+
+	err := sortListedPages(listed, *sortBy, *reverse)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	switch *format {
+	case listFormatTable:
+		writeListTable(listed)
+	case listFormatPaths, listFormatJSON:
+		// Anything meant for other programs to read goes to stdout on its
+		// own, with til's own messages moved out of the way
+		src.LL.SetOutput(os.Stderr)
+
+		if *format == listFormatPaths {
+			for _, lPage := range listed {
+				fmt.Println(lPage.Page.FilePath)
+			}
+		} else {
+			writeListJSON(os.Stdout, listed)
+		}
+
+		// Nor is the output followed by til's closing message
+		os.Exit(0)
+	default:
+		src.Defeat(errors.New(errListFormat))
+	}
+}
+
+// indexedPages returns the content pages in the set, newest first, numbered
+// from one
+func indexedPages(pageSet []*pages.Page) []*listedPage {
+	indexed := []*listedPage{}
+
+	for _, page := range pageSet {
+		if page.IsContentPage() {
+			indexed = append(indexed, &listedPage{Page: page})
+		}
+	}
+
+	_ = sortListedPages(indexed, listSortDate, false)
+
+	for i, lPage := range indexed {
+		lPage.Index = i + 1
+	}
+
+	return indexed
+}
+
+// matches returns true if the page passes every part of the filter
+func (filter *pageFilter) matches(page *pages.Page) bool {
+	if filter.pendingOnly && !page.IsPending() {
+		return false
+	}
+
+	if filter.title != "" && !strings.Contains(strings.ToLower(page.Title), filter.title) {
+		return false
+	}
+
+	if !filter.since.IsZero() && page.CreatedAt().Before(filter.since) {
+		return false
+	}
+
+	if !filter.until.IsZero() && !page.CreatedAt().Before(filter.until) {
+		return false
+	}
+
+	if filter.tagName != "" && !pageHasTag(page, filter.tagName) {
+		return false
+	}
+
+	return true
+}
+
+// pageHasTag returns true if the page has the tag, or a child of the tag
+func pageHasTag(page *pages.Page, tagName string) bool {
+	for _, tag := range page.Tags() {
+		if tag.Name == tagName || strings.HasPrefix(tag.Name, tagName+pages.TagSeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseListDate reads a YYYY-MM-DD date from the named flag. An empty value
+// is the zero time
+func parseListDate(flagName string, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	date, err := time.ParseInLocation(listDateFormat, value, time.Local)
+	if err != nil {
+		src.Defeat(fmt.Errorf(errListDate, flagName, value))
+	}
+
+	return date
+}
+
+// sortListedPages sorts the pages in place. Pages that compare equal keep
+// their newest-first order
+func sortListedPages(listed []*listedPage, sortBy string, reverse bool) error {
+	var less func(a *pages.Page, b *pages.Page) bool
+
+	switch sortBy {
+	case listSortDate:
+		less = func(a *pages.Page, b *pages.Page) bool { return a.CreatedAt().After(b.CreatedAt()) }
+	case listSortTitle:
+		less = func(a *pages.Page, b *pages.Page) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	case listSortUpdated:
+		less = func(a *pages.Page, b *pages.Page) bool { return a.LastModifiedAt().After(b.LastModifiedAt()) }
+	default:
+		return errors.New(errListSort)
+	}
+
+	sort.SliceStable(listed, func(i, j int) bool {
+		if reverse {
+			return less(listed[j].Page, listed[i].Page)
+		}
+
+		return less(listed[i].Page, listed[j].Page)
+	})
+
+	return nil
+}
+
+// writeListJSON writes the pages to w as a JSON array
+func writeListJSON(w io.Writer, listed []*listedPage) {
+	type jsonPage struct {
+		Index   int        `json:"index"`
+		Title   string     `json:"title"`
+		Date    time.Time  `json:"date"`
+		Updated *time.Time `json:"updated,omitempty"`
+		Tags    []string   `json:"tags"`
+		State   string     `json:"state"`
+		Slug    string     `json:"slug"`
+		Path    string     `json:"path"`
+	}
+
+	jPages := []jsonPage{}
+
+	for _, lPage := range listed {
+		page := lPage.Page
+
+		tagNames := []string{}
+		for _, tag := range page.Tags() {
+			tagNames = append(tagNames, tag.Name)
+		}
+
+		var updated *time.Time
+		if modified := page.LastModifiedAt(); !modified.IsZero() {
+			updated = &modified
+		}
+
+		jPages = append(jPages, jsonPage{
+			Index:   lPage.Index,
+			Title:   page.Title,
+			Date:    page.CreatedAt(),
+			Updated: updated,
+			Tags:    tagNames,
+			State:   page.PublishState(),
+			Slug:    page.Slug(),
+			Path:    page.FilePath,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(jPages)
+	if err != nil {
+		src.Defeat(err)
+	}
+}
+
+// writeListTable writes the pages out as lines of the log
+func writeListTable(listed []*listedPage) {
+	for _, lPage := range listed {
+		page := lPage.Page
+
+		src.Progress(
+			fmt.Sprintf(
+				"%3d %s %-40s %s %s",
+				lPage.Index,
+				page.PrettyDate(),
+				page.Title,
+				src.Blue(page.PublishState()),
+				filepath.Base(page.FilePath),
+			),
+		)
+	}
+
+	if len(listed) == 0 {
+		src.Progress("no pages")
+	}
+}
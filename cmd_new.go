@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	errNoTemplate       = "there is no template called '%s' in %s, try one of: %s"
+	errNoTemplates      = "there is no template called '%s', and no templates in %s"
+	errStdinPrompts     = "template '%s' asks questions, which can't be answered once the body has been read from stdin, use -m \"<body>\" instead"
+	errTagTemplateUnset = "tag '%s' uses template '%s', but there is no such template in %s"
+)
+
+// newPageOptions are the choices 'til new' offers for a new page
+type newPageOptions struct {
+	body         string
+	bodyOnStdin  bool
+	frontMatter  yaml.MapSlice
+	openEditor   bool
+	tagNames     pages.TagList
+	templateName string
+}
+
+// newCommand creates a new page, like 'til <title>' does, but optionally
+// from a named template, with tags already set, and with a body taken from
+// -m or piped in on stdin (which -m - asks for explicitly). Pages given a
+// body aren't opened in the editor, unless -edit says to. Without -template, the template configured for the
+// first of the tags that has one in tagTemplates is used, and failing that
+// the 'default' template, if there is one
+// Example:
+//  > til new -template howto -tags go,testing Table-driven tests
+//  > echo "Use t.Run" | til new -tags go Subtests
+func newCommand(args []string) {
+	flags := flag.NewFlagSet("new", flag.ExitOnError)
+	body := flags.String("m", "", "sets the body of the page, '-' reads it from stdin, as happens when it's piped in")
+	openEditor := flags.Bool("edit", false, "opens the page in the editor even when it was given a body")
+	tagList := flags.String("tags", "", "sets the page's tags, separated by commas")
+	templateName := flags.String("template", "", "creates the page from the template with this name")
+	_ = flags.Parse(args)
+
+	title := pages.Titles.Case(strings.Join(flags.Args(), " "))
+	if strings.TrimSpace(title) == "" {
+		src.Defeat(errors.New(errNoTitle))
+	}
+
+	// Once the body has been read from stdin, there's nothing left there to
+	// answer a template's questions with
+	bodyOnStdin := false
+
+	switch *body {
+	case "-":
+		*body = stdinBody()
+		bodyOnStdin = true
+	case "":
+		*body = pipedBody()
+		bodyOnStdin = *body != ""
+	}
+
+	src.BuildTargetDirectory()
+
+	createNewPage(title, &newPageOptions{
+		body:         *body,
+		bodyOnStdin:  bodyOnStdin,
+		openEditor:   *openEditor || strings.TrimSpace(*body) == "",
+		tagNames:     pages.ParseTagList(*tagList),
+		templateName: *templateName,
+	})
+}
+
+// chooseTemplate returns the template for a new page with the given tags:
+// the named one, the one configured for the first tag that has one, or the
+// default template. It returns nil if there's nothing to choose
+func chooseTemplate(root string, name string, tagNames pages.TagList) *pages.Template {
+	tmplDir := filepath.Join(root, pages.TemplateDirectory)
+
+	if name != "" {
+		tmpl := loadTemplate(root, name)
+		if tmpl == nil {
+			names := pages.TemplateNames(root)
+			if len(names) == 0 {
+				src.Defeat(fmt.Errorf(errNoTemplates, name, tmplDir))
+			}
+
+			src.Defeat(fmt.Errorf(errNoTemplate, name, tmplDir, strings.Join(names, ", ")))
+		}
+
+		return tmpl
+	}
+
+	tagTemplates := configuredTagTemplates()
+
+	for _, tagName := range tagNames {
+		name, ok := tagTemplates[pages.Normaliser.Normalise(tagName)]
+		if !ok {
+			continue
+		}
+
+		tmpl := loadTemplate(root, name)
+		if tmpl == nil {
+			src.Defeat(fmt.Errorf(errTagTemplateUnset, tagName, name, tmplDir))
+		}
+
+		return tmpl
+	}
+
+	return loadTemplate(root, pages.DefaultTemplateName)
+}
+
+// configuredTagTemplates returns the tagTemplates map in the config, which
+// maps tag names onto the template new pages with that tag are created from
+func configuredTagTemplates() map[string]string {
+	tagTemplates := map[string]string{}
+
+	tmplMap, err := src.GlobalConfig.Map("tagTemplates")
+	if err != nil {
+		return tagTemplates
+	}
+
+	for tagName, name := range tmplMap {
+		tagTemplates[pages.Normaliser.Normalise(tagName)] = fmt.Sprintf("%v", name)
+	}
+
+	return tagTemplates
+}
+
+// loadTemplate reads the named template, or returns nil if there is no such template
+func loadTemplate(root string, name string) *pages.Template {
+	tmpl, err := pages.LoadTemplate(root, name)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	return tmpl
+}
+
+// pipedBody returns whatever was piped in on stdin, or "" if stdin is a
+// terminal or nothing was piped in
+func pipedBody() string {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return ""
+	}
+
+	return stdinBody()
+}
+
+// stdinBody returns everything on stdin, or "" if that is only whitespace
+func stdinBody() string {
+	data, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	if strings.TrimSpace(string(data)) == "" {
+		return ""
+	}
+
+	return string(data)
+}
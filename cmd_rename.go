@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	errRenameArgs = "usage: til rename <page> <new title>"
+
+	statusRenameLinks    = "rewriting links"
+	statusRenamePage     = "renaming page"
+	statusRenameRedirect = "leaving a redirect"
+)
+
+// renameCommand changes a page's title, renames its file to match, points
+// every link to it at the new file, and leaves a redirect at the old URL
+// Example:
+//  > til rename 3 Table-driven tests in Go
+func renameCommand(args []string) {
+	if len(args) < 2 {
+		src.Defeat(errors.New(errRenameArgs))
+	}
+
+	title := pages.Titles.Case(strings.TrimSpace(strings.Join(args[1:], " ")))
+	if title == "" {
+		src.Defeat(errors.New(errNoTitle))
+	}
+
+	pageSet := loadPages()
+	page := findListedPage(pageSet, args[0])
+
+	renamePage(pageSet, page, title)
+
+	buildContent()
+}
+
+// applyCustomSlugs renames the pages whose front-matter sets a slug that
+// their file names don't have yet, as if they had been renamed to their
+// current titles
+func applyCustomSlugs(pageSet []*pages.Page) {
+	for _, page := range pageSet {
+		if page.IsContentPage() && page.SlugPending() {
+			renamePage(pageSet, page, page.Title)
+		}
+	}
+}
+
+// renamePage retitles the page and takes care of everything that refers to
+// it by its old file name
+func renamePage(pageSet []*pages.Page, page *pages.Page, title string) {
+	src.Info(statusRenamePage)
+
+	oldPath := page.FilePath
+
+	err := page.Retitle(title)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Progress(fmt.Sprintf("%s -> %s", filepath.Base(oldPath), filepath.Base(page.FilePath)))
+
+	if oldPath == page.FilePath {
+		return
+	}
+
+	oldName := pageName(oldPath)
+	newName := pageName(page.FilePath)
+
+	src.Info(statusRenameLinks)
+
+	changed := rewriteLinks(linkingFiles(pageSet), oldName, newName)
+	if changed == 0 {
+		src.Progress("no links to the page")
+	}
+
+	// The loaded pages have to match their files, or saving one of them later
+	// (ie: when it's renamed too) would put the old links back
+	for _, other := range pageSet {
+		other.Content, _ = pages.RewriteLinks(other.Content, oldName, newName)
+	}
+
+	src.Info(statusRenameRedirect)
+
+	stubPath := redirectStubPath(oldPath)
+
+	err = pages.WriteRedirectStub(stubPath, newName+"."+pages.RedirectExtension)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Progress(stubPath)
+
+	// Redirects left by earlier renames should skip straight to the new page
+	retargetRedirectStubs(filepath.Dir(oldPath), oldName, newName)
+}
+
+// linkingFiles returns the paths to the files that can link to pages: the
+// content pages, and the tag description files
+func linkingFiles(pageSet []*pages.Page) []string {
+	filePaths := []string{}
+
+	for _, page := range pageSet {
+		if page.IsContentPage() {
+			filePaths = append(filePaths, page.FilePath)
+		}
+	}
+
+	root, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	_ = filepath.Walk(filepath.Join(root, pages.TagDirectory), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && filepath.Ext(path) == "."+pages.FileExtension {
+			filePaths = append(filePaths, path)
+		}
+
+		return nil
+	})
+
+	return filePaths
+}
+
+// pageName returns a page's file name without its extension
+func pageName(filePath string) string {
+	return strings.TrimSuffix(filepath.Base(filePath), "."+pages.FileExtension)
+}
+
+// redirectStubPath returns the path to the redirect stub for the page file
+func redirectStubPath(filePath string) string {
+	return strings.TrimSuffix(filePath, "."+pages.FileExtension) + "." + pages.RedirectExtension
+}
+
+// retargetRedirectStubs points the redirect stubs in the directory that go to
+// the page named oldName at the page named newName instead
+func retargetRedirectStubs(dir string, oldName string, newName string) {
+	stubPaths, _ := filepath.Glob(filepath.Join(dir, "*."+pages.RedirectExtension))
+
+	for _, stubPath := range stubPaths {
+		data, err := ioutil.ReadFile(stubPath)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		if pages.RedirectTarget(string(data)) != oldName+"."+pages.RedirectExtension {
+			continue
+		}
+
+		err = pages.WriteRedirectStub(stubPath, newName+"."+pages.RedirectExtension)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		src.Progress(stubPath)
+	}
+}
+
+// rewriteLinks changes the links to the page named oldName in each of the
+// files so that they point at newName instead, and returns how many files changed
+func rewriteLinks(filePaths []string, oldName string, newName string) int {
+	changed := 0
+
+	for _, filePath := range filePaths {
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		content, count := pages.RewriteLinks(string(data), oldName, newName)
+		if count == 0 {
+			continue
+		}
+
+		err = ioutil.WriteFile(filePath, []byte(content), 0644)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		src.Progress(fmt.Sprintf("%s (%d %s)", filePath, count, pluralize(count, "link")))
+		changed++
+	}
+
+	return changed
+}
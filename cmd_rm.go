@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	// trashDirectory is where removed pages are kept, in the root of the
+	// target. It is git-ignored, so removed pages are never published
+	trashDirectory = ".trash"
+
+	errRestoreExists = "can't restore %s, there is already a page at %s"
+	errRmExists      = "can't remove %s, there is already a page called that in the trash"
+
+	statusInboundLinks = "these still link to it"
+	statusRestorePage  = "restoring page"
+	statusRmPage       = "moving page to the trash"
+	statusRmRedirect   = "leaving a redirect"
+	statusTrash        = "pages in the trash"
+	statusTrashEmpty   = "the trash is empty"
+)
+
+// rmCommand moves a page out of the docs directory and into the trash, from
+// where 'til restore' can bring it back. Optionally, a redirect is left at the
+// page's URL, to the URL given by -redirect or by removedPageRedirect in the config
+// Example:
+//  > til rm 3
+func rmCommand(args []string) {
+	flags := flag.NewFlagSet("rm", flag.ExitOnError)
+	redirectURL := flags.String("redirect", src.GlobalConfig.UString("removedPageRedirect", ""), "leaves a redirect to this URL at the page's URL")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		src.Defeat(errors.New(errNoPageRef))
+	}
+
+	pageSet := loadPages()
+	page := findListedPage(pageSet, flags.Arg(0))
+
+	trashPage(page)
+
+	if *redirectURL != "" {
+		src.Info(statusRmRedirect)
+
+		stubPath := redirectStubPath(page.FilePath)
+
+		err := pages.WriteRedirectStub(stubPath, *redirectURL)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		src.Progress(stubPath)
+	}
+
+	reportInboundLinks(pageSet, page)
+
+	buildContent()
+}
+
+// restoreCommand moves a page out of the trash and back into the docs
+// directory, removing any redirect left at its URL. With no page given, it
+// lists the pages in the trash
+// Example:
+//  > til restore 2020-04-20T14-52-57-new-title-here
+func restoreCommand(args []string) {
+	trashed := trashedPages()
+
+	if len(args) == 0 {
+		listTrash(trashed)
+		return
+	}
+
+	page, err := pages.Find(trashed, args[0])
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	restorePage(page)
+
+	buildContent()
+}
+
+// ensureGitIgnored adds the entry to the .gitignore in the root of the
+// target, unless it is already in there. Targets set up before the entry was
+// part of 'til init' are brought up to date this way
+func ensureGitIgnored(root string, entry string) {
+	ignorePath := filepath.Join(root, ".gitignore")
+
+	data, err := ioutil.ReadFile(ignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		src.Defeat(err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == entry {
+			return
+		}
+	}
+
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+
+	err = ioutil.WriteFile(ignorePath, append(data, []byte(entry+"\n")...), 0644)
+	if err != nil {
+		src.Defeat(err)
+	}
+}
+
+// inboundLinks returns the paths to the files that link to the page, other
+// than the page itself
+func inboundLinks(filePaths []string, page *pages.Page) []string {
+	name := pageName(page.FilePath)
+	linking := []string{}
+
+	for _, filePath := range filePaths {
+		if filePath == page.FilePath {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		if pages.HasLinkTo(string(data), name) {
+			linking = append(linking, filePath)
+		}
+	}
+
+	return linking
+}
+
+// listTrash writes out the pages in the trash, newest first
+func listTrash(trashed []*pages.Page) {
+	if len(trashed) == 0 {
+		src.Info(statusTrashEmpty)
+		return
+	}
+
+	src.Info(statusTrash)
+
+	for _, page := range trashed {
+		src.Progress(fmt.Sprintf("%s  %s  %s", page.PrettyDate(), pageName(page.FilePath), page.Title))
+	}
+}
+
+// reportInboundLinks lists the pages and tag description files that link to
+// the page, as those links no longer go where they used to
+func reportInboundLinks(pageSet []*pages.Page, page *pages.Page) {
+	linking := inboundLinks(linkingFiles(pageSet), page)
+	if len(linking) == 0 {
+		return
+	}
+
+	src.Info(statusInboundLinks)
+
+	for _, filePath := range linking {
+		src.Progress(src.Red(filePath))
+	}
+}
+
+// restorePage moves the page from the trash back into the docs directory
+func restorePage(page *pages.Page) {
+	src.Info(statusRestorePage)
+
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	restoredPath := filepath.Join(tDir, filepath.Base(page.FilePath))
+	if _, err := os.Stat(restoredPath); err == nil {
+		src.Defeat(fmt.Errorf(errRestoreExists, filepath.Base(page.FilePath), restoredPath))
+	}
+
+	err = os.Rename(page.FilePath, restoredPath)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Progress(fmt.Sprintf("%s -> %s", page.FilePath, restoredPath))
+
+	// A redirect left by 'til rm' would hide the page again
+	stubPath := redirectStubPath(restoredPath)
+
+	data, err := ioutil.ReadFile(stubPath)
+	if err == nil && pages.RedirectTarget(string(data)) != "" {
+		err = os.Remove(stubPath)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		src.Progress(fmt.Sprintf("removed %s", stubPath))
+	}
+}
+
+// trashDir returns the path to the trash in the root of the target
+func trashDir() string {
+	root, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	return filepath.Join(root, trashDirectory)
+}
+
+// trashedPages loads the pages in the trash, newest first
+func trashedPages() []*pages.Page {
+	filePaths := pageFilePaths(trashDir())
+	trashed := []*pages.Page{}
+
+	for i := len(filePaths) - 1; i >= 0; i-- {
+		page, err := pages.Load(filePaths[i])
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		trashed = append(trashed, page)
+	}
+
+	return trashed
+}
+
+// trashPage moves the page from the docs directory into the trash. The page's
+// FilePath is left pointing at where it used to be
+func trashPage(page *pages.Page) {
+	src.Info(statusRmPage)
+
+	tDir := trashDir()
+
+	ensureGitIgnored(filepath.Dir(tDir), trashDirectory+"/")
+
+	err := os.MkdirAll(tDir, os.ModePerm)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	trashedPath := filepath.Join(tDir, filepath.Base(page.FilePath))
+	if _, err := os.Stat(trashedPath); err == nil {
+		src.Defeat(fmt.Errorf(errRmExists, filepath.Base(page.FilePath)))
+	}
+
+	err = os.Rename(page.FilePath, trashedPath)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Progress(fmt.Sprintf("%s -> %s", page.FilePath, trashedPath))
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	// maxCapturedOutput is how much of each of stdout and stderr goes on the
+	// page, in bytes. The end of the output is kept, since that's usually where
+	// the interesting part is
+	maxCapturedOutput = 32 * 1024
+
+	errRunArgs = "usage: til run [-title <title>] [-tags <tags>] -- <command> [args...]"
+
+	statusRunCommand = "running"
+)
+
+// commandRun is the record of a command that was run: what it was, what it
+// wrote out, how it ended, and how long it took
+type commandRun struct {
+	Args      []string
+	Duration  time.Duration
+	ExitCode  int
+	Stderr    string
+	StderrCut int
+	Stdout    string
+	StdoutCut int
+}
+
+// wrapperCommands run the command that follows them, so the page is tagged
+// with that command's name instead. Each maps onto its flags that take a
+// value, as the argument after the flag
+var wrapperCommands = map[string]map[string]bool{
+	"command": {},
+	"doas":    {"-C": true, "-u": true},
+	"env":     {"-C": true, "-u": true, "--chdir": true, "--unset": true},
+	"exec":    {"-a": true},
+	"nice":    {"-n": true, "--adjustment": true},
+	"nohup":   {},
+	"sudo": {
+		"-C": true, "-D": true, "-g": true, "-h": true, "-p": true, "-R": true,
+		"-r": true, "-T": true, "-t": true, "-U": true, "-u": true,
+		"--chdir": true, "--chroot": true, "--close-from": true,
+		"--command-timeout": true, "--group": true, "--host": true,
+		"--other-user": true, "--prompt": true, "--role": true, "--type": true,
+		"--user": true,
+	},
+	"time": {"-f": true, "-o": true, "--format": true, "--output": true},
+}
+
+// tailBuffer keeps the last max bytes written to it, and counts the rest
+type tailBuffer struct {
+	buf     []byte
+	dropped int
+	max     int
+}
+
+// runCommand runs a command, then creates a page with the command and what
+// it wrote out in it, tagged with the command's name, and opens the page in
+// the editor so it can be written up
+// Example:
+//  > til run -tags testing -- go test ./...
+func runCommand(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	tagList := flags.String("tags", "", "adds these tags to the page, separated by commas")
+	title := flags.String("title", "", "sets the page's title, instead of using the command")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		src.Defeat(errors.New(errRunArgs))
+	}
+
+	src.Info(fmt.Sprintf("%s %s", statusRunCommand, shellJoin(flags.Args())))
+
+	run := runAndCapture(flags.Args())
+
+	if strings.TrimSpace(*title) == "" {
+		*title = shellJoin(run.Args)
+	}
+
+	src.BuildTargetDirectory()
+
+	createNewPage(*title, &newPageOptions{
+		body:       run.Markdown(),
+		openEditor: true,
+		tagNames:   runTags(run.Args, pages.ParseTagList(*tagList)),
+	})
+}
+
+// runTags returns the tags for a page about the command: the given ones, and
+// the command's name, unless that's already among them
+func runTags(args []string, tagNames pages.TagList) pages.TagList {
+	name := commandName(args)
+	if name == "" {
+		return tagNames
+	}
+
+	for _, tagName := range tagNames {
+		if pages.Normaliser.Normalise(tagName) == name {
+			return tagNames
+		}
+	}
+
+	return append(tagNames, name)
+}
+
+// commandName returns the name of the command that the arguments run, as a
+// tag: without its path or extension, and skipping past wrappers like sudo
+// and env along with their flags and variables, ie: "deploy" for
+// "sudo -u deploy -E FOO=1 ./deploy.sh"
+func commandName(args []string) string {
+	// The flags of the wrapper the command is being run by, if it is
+	var valueFlags map[string]bool
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if valueFlags != nil {
+			if strings.HasPrefix(arg, "-") {
+				if valueFlags[arg] {
+					i++
+				}
+
+				continue
+			}
+
+			// Variables set for the command, ie: env FOO=1
+			if strings.Contains(arg, "=") {
+				continue
+			}
+		}
+
+		name := filepath.Base(arg)
+
+		if flags, ok := wrapperCommands[name]; ok {
+			valueFlags = flags
+			continue
+		}
+
+		return pages.Normaliser.Normalise(strings.TrimSuffix(name, filepath.Ext(name)))
+	}
+
+	return ""
+}
+
+// Markdown returns the command and its output as Markdown for the page body
+func (run *commandRun) Markdown() string {
+	body := pages.CodeBlock("sh", "$ "+shellJoin(run.Args))
+
+	body += fmt.Sprintf("\nExited with status %d after %s.\n", run.ExitCode, run.Duration)
+
+	if run.Stdout == "" && run.Stderr == "" {
+		return body + "\nIt wrote nothing out.\n"
+	}
+
+	if run.Stdout != "" {
+		body += "\n" + outputLabel("stdout", run.StdoutCut) + ":\n\n" + pages.CodeBlock("", run.Stdout)
+	}
+
+	if run.Stderr != "" {
+		body += "\n" + outputLabel("stderr", run.StderrCut) + ":\n\n" + pages.CodeBlock("", run.Stderr)
+	}
+
+	return body
+}
+
+// runAndCapture runs the command, showing its output as it goes as well as
+// capturing it. A command that fails is still a command that ran, only a
+// command that can't be started at all is an error
+func runAndCapture(args []string) *commandRun {
+	stdout := &tailBuffer{max: maxCapturedOutput}
+	stderr := &tailBuffer{max: maxCapturedOutput}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = io.MultiWriter(os.Stdout, stdout)
+	cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start)
+
+	exitCode := 0
+
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			src.Defeat(err)
+		}
+
+		exitCode = exitErr.ExitCode()
+	}
+
+	return &commandRun{
+		Args:      args,
+		Duration:  duration.Round(time.Millisecond),
+		ExitCode:  exitCode,
+		Stderr:    stderr.String(),
+		StderrCut: stderr.Dropped(),
+		Stdout:    stdout.String(),
+		StdoutCut: stdout.Dropped(),
+	}
+}
+
+// outputLabel names one of the command's outputs, noting how much of the
+// start of it was left out, if any was
+func outputLabel(name string, cut int) string {
+	if cut == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s, without the first %d bytes", name, cut)
+}
+
+// Write keeps the end of what has been written so far
+func (tb *tailBuffer) Write(p []byte) (int, error) {
+	tb.buf = append(tb.buf, p...)
+
+	// Trimming in batches saves copying on every write
+	if len(tb.buf) > 2*tb.max {
+		tb.trim()
+	}
+
+	return len(p), nil
+}
+
+// Dropped returns how many bytes from the start have been left out
+func (tb *tailBuffer) Dropped() int {
+	tb.trim()
+	return tb.dropped
+}
+
+// String returns the last max bytes written, starting at a line where possible
+func (tb *tailBuffer) String() string {
+	tb.trim()
+	return string(tb.buf)
+}
+
+// trim drops everything before the last max bytes, along with what is left
+// of the line that was cut through
+func (tb *tailBuffer) trim() {
+	if len(tb.buf) <= tb.max {
+		return
+	}
+
+	cut := len(tb.buf) - tb.max
+	if idx := bytes.IndexByte(tb.buf[cut:], '\n'); idx >= 0 && idx < len(tb.buf)-cut-1 {
+		cut += idx + 1
+	}
+
+	tb.dropped += cut
+	tb.buf = append([]byte{}, tb.buf[cut:]...)
+}
+
+// shellJoin joins the arguments into a command line that can be pasted back
+// into a shell, quoting the ones that need it
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}!#~") {
+			quoted[i] = arg
+			continue
+		}
+
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+
+	return strings.Join(quoted, " ")
+}
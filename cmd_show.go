@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	errNoPageIndex = "there is no page %d, 'til list' shows the page numbers"
+)
+
+// showCommand writes a page out to the terminal. The page can be referred to
+// by its file name or slug, or by the number 'til list' shows next to it
+// Example:
+//  > til show 3
+func showCommand(args []string) {
+	if len(args) == 0 {
+		src.Defeat(errors.New(errNoPageRef))
+	}
+
+	page := findListedPage(loadPagesWithHistory(), args[0])
+
+	fmt.Print(renderPage(page))
+}
+
+// findListedPage returns the page the reference refers to. A reference that
+// is a number is the page's position in 'til list', anything else is passed
+// on to pages.Find
+func findListedPage(pageSet []*pages.Page, ref string) *pages.Page {
+	idx, err := strconv.Atoi(ref)
+	if err != nil {
+		page, err := pages.Find(pageSet, ref)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		return page
+	}
+
+	indexed := indexedPages(pageSet)
+	if idx < 1 || idx > len(indexed) {
+		src.Defeat(fmt.Errorf(errNoPageIndex, idx))
+	}
+
+	return indexed[idx-1].Page
+}
+
+// renderPage creates the terminal version of the page: a header made from
+// the front-matter, followed by the rendered content
+func renderPage(page *pages.Page) string {
+	header := src.Green(page.Title) + "\n"
+
+	details := []string{page.PrettyDate(), page.PublishState()}
+
+	if page.IsUpdated() {
+		details = append(details, "updated "+page.PrettyUpdatedDate())
+	}
+
+	tagNames := []string{}
+	for _, tag := range page.Tags() {
+		tagNames = append(tagNames, tag.Name)
+	}
+
+	if len(tagNames) > 0 {
+		details = append(details, strings.Join(tagNames, ", "))
+	}
+
+	header += src.Blue(strings.Join(details, " · ")) + "\n\n"
+
+	// The header already has the title in it, so the page's own heading can go
+	content := strings.TrimLeft(page.Content, "\n")
+	content = strings.TrimPrefix(content, fmt.Sprintf("# %s\n", page.Title))
+
+	return header + src.RenderMarkdown(strings.TrimLeft(content, "\n"))
+}
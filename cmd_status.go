@@ -0,0 +1,247 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/repo"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	statusGenerated   = "generated pages"
+	statusRemote      = "remote"
+	statusUnpublished = "unpublished pages"
+)
+
+// statusCommand summarises what -save would do for the target: the pages
+// that have changed since the last commit, whether the generated pages need
+// rebuilding, and how the target compares to its remote
+// Example:
+//  > til status -offline
+func statusCommand(args []string) {
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	offline := flags.Bool("offline", false, "does not fetch from the remote before comparing")
+	_ = flags.Parse(args)
+
+	rejectArgs(flags)
+
+	r := openTargetRepo()
+
+	reportUnpublishedPages(r)
+	reportGeneratedPages()
+	reportRemote(r, *offline)
+}
+
+// reportUnpublishedPages lists the pages that are new, modified, or deleted
+// since the last commit
+func reportUnpublishedPages(r *git.Repository) {
+	src.Info(statusUnpublished)
+
+	w, err := r.Worktree()
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	root, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	// Only files in the docs directory are pages, templates and the like aren't
+	docsDir, err := filepath.Rel(root, tDir)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	paths := []string{}
+	for path := range status {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	count := 0
+
+	for _, path := range paths {
+		fStatus := status[path]
+
+		if fStatus.Worktree == git.Unmodified && fStatus.Staging == git.Unmodified {
+			continue
+		}
+
+		if filepath.Ext(path) != "."+pages.FileExtension || !strings.HasPrefix(path, filepath.ToSlash(docsDir)+"/") {
+			continue
+		}
+
+		change := "modified"
+
+		switch {
+		case fStatus.Worktree == git.Untracked || fStatus.Staging == git.Added:
+			change = "new"
+		case fStatus.Worktree == git.Deleted || fStatus.Staging == git.Deleted:
+			change = "deleted"
+		}
+
+		if change != "deleted" {
+			page, err := pages.Load(filepath.Join(root, path))
+			if err != nil {
+				src.Progress(src.Red(fmt.Sprintf("%-8s %s", change, err)))
+				count++
+				continue
+			}
+
+			// Generated pages are covered by reportGeneratedPages
+			if !page.IsContentPage() {
+				continue
+			}
+		}
+
+		src.Progress(fmt.Sprintf("%-8s %s", change, path))
+		count++
+	}
+
+	if count == 0 {
+		src.Progress("nothing to publish")
+	}
+}
+
+// reportGeneratedPages explains whether the index and tag pages need rebuilding
+func reportGeneratedPages() {
+	src.Info(statusGenerated)
+
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	reasons := staleGeneratedPages(loadPages(), tDir)
+	for _, reason := range reasons {
+		src.Progress(reason)
+	}
+
+	if len(reasons) == 0 {
+		src.Progress("up to date")
+	} else {
+		src.Progress("run 'til -build' to rebuild them")
+	}
+}
+
+// reportRemote lists the commits that have not been pushed and whether the
+// remote has commits that are not present locally
+func reportRemote(r *git.Repository, offline bool) {
+	src.Info(statusRemote)
+
+	if !offline {
+		err := repo.Fetch(r)
+		if err != nil {
+			src.Progress(fmt.Sprintf("could not fetch: %s", err.Error()))
+		}
+	}
+
+	div, err := repo.Diverge(r)
+	if err != nil {
+		src.Progress(err.Error())
+		return
+	}
+
+	if len(div.Ahead) == 0 && len(div.Behind) == 0 {
+		src.Progress("up to date")
+		return
+	}
+
+	if len(div.Ahead) > 0 {
+		src.Progress(fmt.Sprintf("%d %s not pushed", len(div.Ahead), pluralize(len(div.Ahead), "commit")))
+
+		for _, c := range div.Ahead {
+			src.Progress(fmt.Sprintf("  %.7s %s", c.Hash.String(), commitSubject(c)))
+		}
+	}
+
+	if len(div.Behind) > 0 {
+		src.Progress(fmt.Sprintf("remote is ahead by %d %s", len(div.Behind), pluralize(len(div.Behind), "commit")))
+	}
+}
+
+// staleGeneratedPages compares the modification times of the content pages
+// with the generated pages that list them, and returns the reasons why the
+// generated pages are out of date. An empty result means they are current
+func staleGeneratedPages(pageSet []*pages.Page, tDir string) []string {
+	reasons := []string{}
+
+	newest := newestModTime(pageSet)
+
+	for _, indexPath := range []string{indexPagePath(tDir), tagIndexPagePath(tDir), untaggedPagePath(tDir)} {
+		if modTime(indexPath).Before(newest) {
+			reasons = append(reasons, fmt.Sprintf("%s is older than the newest page", filepath.Base(indexPath)))
+		}
+	}
+
+	tagMap := pages.NewTagMap(pageSet)
+
+	for _, tagName := range tagMap.SortedTagNames() {
+		tagPath := tagPagePath(tDir, tagName)
+		tagModTime := modTime(tagPath)
+
+		// The tag's description file lives in the target directory, above docs
+		descPath := tagPagePath(filepath.Dir(tDir), tagName)
+
+		if tagModTime.Before(newestModTime(tagMap.PagesFor(tagName))) || tagModTime.Before(modTime(descPath)) {
+			reasons = append(reasons, fmt.Sprintf("%s is missing or out of date", strings.TrimPrefix(tagPath, tDir+"/")))
+		}
+	}
+
+	return reasons
+}
+
+// modTime returns the modification time of a file, or the zero time if it does not exist
+func modTime(filePath string) time.Time {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
+
+// newestModTime returns the latest modification time of the content pages in the set
+func newestModTime(pageSet []*pages.Page) time.Time {
+	newest := time.Time{}
+
+	for _, page := range pageSet {
+		if !page.IsContentPage() {
+			continue
+		}
+
+		if mTime := modTime(page.FilePath); mTime.After(newest) {
+			newest = mTime
+		}
+	}
+
+	return newest
+}
+
+// pluralize appends an "s" to the word unless count is exactly one
+func pluralize(count int, word string) string {
+	if count == 1 {
+		return word
+	}
+
+	return word + "s"
+}
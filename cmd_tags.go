@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	errTagArgs     = "usage: til tag rename <old> <new>, or til tag merge <into> <from> [from...]"
+	errTagNotFound = "no pages are tagged with %v"
+
+	statusTagRename = "renaming tags"
+)
+
+// tagsCommand lists every tag in use along with how many pages use it
+// Example:
+//  > til tags -sort count
+func tagsCommand(args []string) {
+	flags := flag.NewFlagSet("tags", flag.ExitOnError)
+	sortBy := flags.String("sort", "name", "sorts the tags by 'name' or by 'count'")
+	_ = flags.Parse(args)
+
+	tagMap := pages.NewTagMap(loadPages())
+	tagNames := tagMap.SortedTagNames()
+
+	if *sortBy == "count" {
+		sort.SliceStable(tagNames, func(i, j int) bool {
+			return tagMap.Count(tagNames[i]) > tagMap.Count(tagNames[j])
+		})
+	}
+
+	for _, tagName := range tagNames {
+		src.Progress(fmt.Sprintf("%4d %s", tagMap.Count(tagName), tagName))
+	}
+}
+
+// tagCommand rewrites tags across every page in the target, then rebuilds
+// Example:
+//  > til tag rename golang go
+//  > til tag merge go golang Go
+func tagCommand(args []string) {
+	if len(args) < 3 {
+		src.Defeat(errors.New(errTagArgs))
+	}
+
+	renames := map[string]string{}
+
+	switch args[0] {
+	case "rename":
+		if len(args) != 3 {
+			src.Defeat(errors.New(errTagArgs))
+		}
+
+		renames[args[1]] = args[2]
+	case "merge":
+		for _, from := range args[2:] {
+			renames[from] = args[1]
+		}
+	default:
+		src.Defeat(errors.New(errTagArgs))
+	}
+
+	renameTags(renames)
+}
+
+// renameTags renames the tags on every page that uses them, removes the
+// generated pages for the old tags, and rebuilds
+func renameTags(renames map[string]string) {
+	src.Info(statusTagRename)
+
+	changed := 0
+
+	for _, page := range loadPages() {
+		if !page.IsContentPage() {
+			continue
+		}
+
+		if page.RenameTags(renames) {
+			page.Save()
+			src.Progress(page.FilePath)
+			changed++
+		}
+	}
+
+	if changed == 0 {
+		oldNames := []string{}
+		for oldName := range renames {
+			oldNames = append(oldNames, oldName)
+		}
+
+		src.Defeat(fmt.Errorf(errTagNotFound, oldNames))
+	}
+
+	// Rebuilding clears out the pages for the old tags
+	buildContent()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/senorprogrammer/til/repo"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	errUndoForeignCommit = "the last commit (%.7s) was made by %s <%s>, not by til"
+
+	statusRepoReset  = "resetting the unpushed commit"
+	statusRepoRevert = "reverting the pushed commit"
+)
+
+// undoCommand undoes the most recent commit made by -save. If that commit has
+// not been pushed it is simply reset, leaving its changes on disk. If it has
+// been pushed it is reverted with a new commit, which is optionally pushed too.
+// Either way the generated pages are rebuilt afterwards. Changes that were
+// already uncommitted are left out of the revert commit
+// Example:
+//  > til undo -push
+func undoCommand(args []string) {
+	flags := flag.NewFlagSet("undo", flag.ExitOnError)
+	pushRevert := flags.Bool("push", false, "pushes the revert commit to the remote")
+	_ = flags.Parse(args)
+
+	rejectArgs(flags)
+
+	r := openTargetRepo()
+
+	head, err := r.Head()
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	last, err := r.CommitObject(head.Hash())
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	name, email := committer(src.GlobalConfig)
+	if last.Committer.Name != name || last.Committer.Email != email {
+		src.Defeat(fmt.Errorf(errUndoForeignCommit, last.Hash.String(), last.Committer.Name, last.Committer.Email))
+	}
+
+	if last.NumParents() != 1 {
+		src.Defeat(repo.ErrNotRevertable)
+	}
+
+	pushed, err := repo.IsPushed(r, last.Hash)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	if !pushed {
+		src.Info(statusRepoReset)
+
+		err = w.Reset(&git.ResetOptions{Commit: last.ParentHashes[0], Mode: git.MixedReset})
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		src.Progress(fmt.Sprintf("reset '%s' (%.7s), its changes are still on disk", commitSubject(last), last.Hash.String()))
+
+		buildContent()
+
+		return
+	}
+
+	src.Info(statusRepoRevert)
+
+	// Work in progress stays out of the revert commit
+	uncommitted, err := repo.UncommittedPaths(w)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	paths, err := repo.Revert(r, last)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	for _, path := range paths {
+		src.Progress(path)
+	}
+
+	buildContent()
+
+	err = repo.AddChanged(w, uncommitted)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	if len(uncommitted) > 0 {
+		src.Progress(fmt.Sprintf("left %d uncommitted %s out of the revert", len(uncommitted), pluralize(len(uncommitted), "change")))
+	}
+
+	revertMsg := fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.\n", commitSubject(last), last.Hash.String())
+	obj := commit(r, revertMsg)
+
+	src.Info(fmt.Sprintf("committed with '%s' (%.7s)", commitSubject(obj), obj.Hash.String()))
+
+	if *pushRevert {
+		push()
+	}
+}
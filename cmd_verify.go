@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/senorprogrammer/til/repo"
+	"github.com/senorprogrammer/til/src"
+)
+
+const (
+	errNoSigningKey = "no signingKey defined in config, cannot verify signatures"
+
+	statusRepoVerify = "verifying commit signatures"
+)
+
+// verifyCommand checks the signatures on the commits in the target's history
+// against the signing key defined in the config. It fails if any commit is
+// unsigned or carries a bad signature
+// Example:
+//  > til verify -n 10
+func verifyCommand(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	maxCount := flags.Int("n", 0, "only verifies the n most recent commits")
+	_ = flags.Parse(args)
+
+	signingKey := loadSigningKey(src.GlobalConfig)
+	if signingKey == nil {
+		src.Defeat(errors.New(errNoSigningKey))
+	}
+
+	src.Info(statusRepoVerify)
+
+	r := openTargetRepo()
+
+	cIter, err := r.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
+	if err != nil {
+		src.Defeat(err)
+	}
+	defer cIter.Close()
+
+	checked := 0
+	failed := 0
+
+	for {
+		c, err := cIter.Next()
+		if err != nil || (*maxCount > 0 && checked >= *maxCount) {
+			break
+		}
+
+		checked++
+
+		status := src.Green("verified")
+
+		err = repo.VerifyCommit(c, signingKey)
+		if err != nil {
+			failed++
+			status = src.Red(err.Error())
+		}
+
+		src.Progress(fmt.Sprintf("%.7s %s %s", c.Hash.String(), commitSubject(c), status))
+	}
+
+	if failed > 0 {
+		src.Defeat(fmt.Errorf("%d of %d commits could not be verified", failed, checked))
+	}
+}
+
+// commitSubject returns the first line of a commit's message
+func commitSubject(c *object.Commit) string {
+	return strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0]
+}
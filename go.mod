@@ -7,5 +7,7 @@ require (
 	github.com/go-git/go-git/v5 v5.0.0
 	github.com/olebedev/config v0.0.0-20190528211619-364964f3a8e4
 	github.com/stretchr/testify v1.4.0
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	golang.org/x/crypto v0.0.0-20200302210943-78000ba7a073
+	golang.org/x/text v0.3.2
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -1,21 +1,27 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
+	"html"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/olebedev/config"
 	"github.com/senorprogrammer/til/pages"
+	"github.com/senorprogrammer/til/repo"
 	"github.com/senorprogrammer/til/src"
 )
 
@@ -24,18 +30,66 @@ const (
 
 	defaultEditor = "open"
 
+	indexLayoutAlphabetical = "alphabetical"
+	indexLayoutCloud        = "cloud"
+	indexLayoutCounts       = "counts"
+	indexLayoutList         = "list"
+
+	tagIndexName = "tags"
+	untaggedName = "untagged"
+
 	/* -------------------- Messages -------------------- */
 
 	errConfigValueRead = "could not read a required configuration value"
+	errIndexLayout     = "unknown indexLayout '%s', expected 'list', 'counts', 'cloud', or 'alphabetical'"
+	errNoArgs          = "'til %s' takes no arguments, to create a page with this title use: til -- %s %s"
 	errNoTitle         = "title must not be blank"
+	errSlugSeparator   = "unknown slugSeparator '%s', expected one or more of '-', '_', and '.'"
+	errTagDescription  = "could not read the tag descriptions in the config: %s"
+	errTagDirContent   = "%s is a content page, move it out of the '%s' directory, which til rebuilds on every build"
+	errTagPaths        = "%d tag(s) can't have tag pages, rename them with 'til tag rename'"
+	errTagPinned       = "could not pin a page to tag '%s': %s"
+	errTitleCase       = "unknown titleCase '%s', expected 'title', 'sentence', or 'as-typed'"
+	errUnparsablePages = "%d page(s) could not be parsed, 'til fix' may be able to repair them"
 
 	statusDone     = "done"
 	statusIdxBuild = "building index page"
 	statusRepoPush = "pushing to remote"
 	statusRepoSave = "saving uncommitted files"
 	statusTagBuild = "building tag pages"
+	statusTagIndex = "building tag index page"
+	statusUntagged = "building untagged page"
 )
 
+// commands maps sub-command names to the functions that run them. Every
+// argument that follows the sub-command name is passed through to it.
+// Example:
+//  > til -t b verify -n 10
+var commands = map[string]func(args []string){
+	"archive":     archiveCommand,
+	"edit":        editCommand,
+	"fix":         fixCommand,
+	"from-commit": fromCommitCommand,
+	"history":     historyCommand,
+	"init":        initCommand,
+	"lint":        lintCommand,
+	"list":        listCommand,
+	"new":         newCommand,
+	"rename":      renameCommand,
+	"restore":     restoreCommand,
+	"rm":          rmCommand,
+	"run":         runCommand,
+	"show":        showCommand,
+	"status":      statusCommand,
+	"tag":         tagCommand,
+	"tags":        tagsCommand,
+	"undo":        undoCommand,
+	"verify":      verifyCommand,
+}
+
+// stdin is where prompt reads its answers from
+var stdin = bufio.NewReader(os.Stdin)
+
 var (
 	buildFlag     bool
 	listFlag      bool
@@ -67,6 +121,8 @@ func main() {
 	cnf := &src.Config{}
 	cnf.Load()
 
+	configurePages(src.GlobalConfig)
+
 	/* Flaghandling */
 	/* I personally think "flag handling" should be spelled flag-handling
 	   but precedence has been set and we will defer to it.
@@ -92,6 +148,15 @@ func main() {
 		src.Victory(statusDone)
 	}
 
+	/* Sub-commands */
+
+	// A "--" before the first word marks everything after it as a title, so
+	// that titles can start with a sub-command name, ie: til -- fix flaky tests
+	if cmd, ok := commands[flag.Arg(0)]; ok && !isTitleMarked(os.Args) {
+		cmd(flag.Args()[1:])
+		src.Victory(statusDone)
+	}
+
 	src.BuildTargetDirectory()
 
 	/* Page creation */
@@ -103,7 +168,7 @@ func main() {
 		src.Defeat(errors.New(errNoTitle))
 	}
 
-	createNewPage(title)
+	createNewPage(title, &newPageOptions{openEditor: true})
 
 	src.Victory(statusDone)
 }
@@ -111,9 +176,12 @@ func main() {
 /* -------------------- Helper functions -------------------- */
 
 func buildContent() {
-	pages := loadPages()
+	pages := loadPagesWithHistory()
+	applyCustomSlugs(pages)
 	tagMap := buildTagPages(pages)
 
+	buildTagIndexPage(tagMap)
+	buildUntaggedPage(pages)
 	buildIndexPage(pages, tagMap)
 }
 
@@ -124,20 +192,25 @@ func buildIndexPage(pageSet []*pages.Page, tagMap *pages.TagMap) {
 	content := ""
 
 	// Write the tag list into the top of the index
-	tagLinks := []string{}
+	content += indexTagList(
+		tagMap,
+		src.GlobalConfig.UString("indexLayout", indexLayoutList),
+		src.GlobalConfig.UInt("indexTagLimit", 0),
+	)
 
-	for _, tagName := range tagMap.SortedTagNames() {
-		tags := tagMap.Get(tagName)
-		if len(tags) > 0 {
-			tagLinks = append(tagLinks, tags[0].Link())
-		}
+	// Pages without tags aren't on any tag page, so they get a page of their own
+	if untagged := listedPages(pages.Untagged(pageSet)); len(untagged) > 0 {
+		content += fmt.Sprintf(
+			"\n[%d untagged %s](./%s.%s)\n",
+			len(untagged),
+			pluralize(len(untagged), "page"),
+			untaggedName,
+			pages.FileExtension,
+		)
 	}
 
-	content += strings.Join(tagLinks, ", ")
-	content += "\n"
-
 	// Write the page list into the middle of the page
-	content += pagesToHTMLUnorderedList(pageSet)
+	content += pagesToHTMLUnorderedList(pageSet, "")
 	content += "\n"
 
 	// Write the footer content into the bottom of the index
@@ -150,11 +223,45 @@ func buildIndexPage(pageSet []*pages.Page, tagMap *pages.TagMap) {
 		src.Defeat(err)
 	}
 
-	filePath := fmt.Sprintf(
-		"%s/index.%s",
-		tDir,
-		pages.FileExtension,
-	)
+	filePath := indexPagePath(tDir)
+
+	err = ioutil.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Progress(filePath)
+}
+
+// buildTagIndexPage creates the tags.md page, which lists every tag along
+// with how many pages use it and when it was last used
+func buildTagIndexPage(tagMap *pages.TagMap) {
+	src.Info(statusTagIndex)
+
+	content := "## Tags\n\n"
+	content += "| Tag | Pages | Last used |\n"
+	content += "| --- | ---: | --- |\n"
+
+	for _, stat := range tagMap.Stats() {
+		content += fmt.Sprintf(
+			"| %s | %d | %s |\n",
+			tagMap.Get(stat.Name).Link(),
+			stat.Count,
+			stat.LastUsed.Format("Jan 02, 2006"),
+		)
+	}
+
+	// Write the footer content into the bottom of the page
+	content += "\n"
+	content += src.Footer()
+
+	// And write the file to disk
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	filePath := tagIndexPagePath(tDir)
 
 	err = ioutil.WriteFile(filePath, []byte(content), 0644)
 	if err != nil {
@@ -170,6 +277,31 @@ func buildTagPages(pageSet []*pages.Page) *pages.TagMap {
 
 	tagMap := pages.NewTagMap(pageSet)
 
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	// Every tag has to have a page of its own before any of them get written
+	tagErrs := pages.CheckTagPaths(tagMap.SortedTagNames(), isCaseInsensitiveDir(tDir))
+	if len(tagErrs) > 0 {
+		for _, tagErr := range tagErrs {
+			src.Progress(src.Red(tagErr.Error()))
+		}
+
+		src.Defeat(fmt.Errorf(errTagPaths, len(tagErrs)))
+	}
+
+	root, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	configuredDescs := configuredTagDescriptions(src.GlobalConfig)
+
+	cleanTagDirectory(tDir)
+	removeLegacyTagPages(tDir)
+
 	var wGroup sync.WaitGroup
 
 	for _, tagName := range tagMap.SortedTagNames() {
@@ -178,28 +310,73 @@ func buildTagPages(pageSet []*pages.Page) *pages.TagMap {
 		go func(tagName string) {
 			defer wGroup.Done()
 
+			// Tag pages for hierarchical tags live in sub-directories, so their
+			// links need to find their way back up to the docs directory
+			rootPath := strings.Repeat("../", pages.TagDepth(tagName))
+
+			desc := tagDescription(root, tagName, configuredDescs)
+
 			content := fmt.Sprintf("## %s\n\n", tagName)
 
+			if desc.Intro != "" {
+				content += desc.Intro + "\n\n"
+			}
+
+			// Link up to the parent tag and down to the child tags
+			if parentName := pages.ParentTagName(tagName); parentName != "" {
+				parent := pages.NewTag(parentName, nil)
+				content += fmt.Sprintf("Part of %s\n\n", parent.RelativeLink(rootPath, parentName))
+			}
+
+			childLinks := []string{}
+			for _, childName := range tagMap.Children(tagName) {
+				child := pages.NewTag(childName, nil)
+				childLinks = append(childLinks, child.RelativeLink(rootPath, child.ShortName()))
+			}
+
+			if len(childLinks) > 0 {
+				content += fmt.Sprintf("Includes %s\n\n", strings.Join(childLinks, ", "))
+			}
+
+			relatedLinks := []string{}
+			for _, relatedName := range desc.Related {
+				related := pages.NewTag(pages.Normaliser.Normalise(relatedName), nil)
+				relatedLinks = append(relatedLinks, related.RelativeLink(rootPath, related.Name))
+			}
+
+			if len(relatedLinks) > 0 {
+				content += fmt.Sprintf("Related %s\n\n", strings.Join(relatedLinks, ", "))
+			}
+
+			// Pinned pages go above the rest, and aren't repeated below them
+			pinned := pinnedPages(pageSet, tagName, desc)
+			taggedPages := tagMap.PagesFor(tagName)
+
+			if len(pinned) > 0 {
+				content += "### Pinned\n\n"
+				content += pagesToHTMLUnorderedList(pinned, rootPath)
+
+				taggedPages = pagesExcept(taggedPages, pinned)
+				if len(taggedPages) > 0 {
+					content += "\n### Everything else\n\n"
+				}
+			}
+
 			// Write the page list into the middle of the page
-			content += pagesToHTMLUnorderedList(tagMap.PagesFor(tagName))
+			content += pagesToHTMLUnorderedList(taggedPages, rootPath)
 
 			// Write the footer content into the bottom of the page
 			content += "\n"
 			content += src.Footer()
 
 			// And write the file to disk
-			tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+			filePath := tagPagePath(tDir, tagName)
+
+			err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
 			if err != nil {
 				src.Defeat(err)
 			}
 
-			filePath := fmt.Sprintf(
-				"%s/%s.%s",
-				tDir,
-				tagName,
-				pages.FileExtension,
-			)
-
 			err = ioutil.WriteFile(filePath, []byte(content), 0644)
 			if err != nil {
 				src.Defeat(err)
@@ -214,19 +391,273 @@ func buildTagPages(pageSet []*pages.Page) *pages.TagMap {
 	return tagMap
 }
 
-func createNewPage(title string) {
+// buildUntaggedPage creates the untagged.md page, which lists the pages that
+// don't have any tags and so don't appear on any tag page
+func buildUntaggedPage(pageSet []*pages.Page) {
+	src.Info(statusUntagged)
+
+	content := "## Untagged\n\n"
+
+	// Write the page list into the middle of the page
+	content += pagesToHTMLUnorderedList(pages.Untagged(pageSet), "")
+
+	// Write the footer content into the bottom of the page
+	content += "\n"
+	content += src.Footer()
+
+	// And write the file to disk
 	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
 	if err != nil {
 		src.Defeat(err)
 	}
 
-	page := pages.NewPage(title, tDir)
+	filePath := untaggedPagePath(tDir)
+
+	err = ioutil.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	src.Progress(filePath)
+}
+
+// cleanTagDirectory removes the tag pages left by the previous build, so that
+// pages for tags that are no longer used don't hang around. It refuses to
+// touch the directory if a content page has found its way into it
+func cleanTagDirectory(tDir string) {
+	tagDir := filepath.Join(tDir, pages.TagDirectory)
+
+	err := filepath.Walk(tagDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != "."+pages.FileExtension {
+			return err
+		}
+
+		page, err := pages.Load(path)
+		if err == nil && page.IsContentPage() {
+			return fmt.Errorf(errTagDirContent, path, pages.TagDirectory)
+		}
+
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		src.Defeat(err)
+	}
+
+	err = os.RemoveAll(tagDir)
+	if err != nil {
+		src.Defeat(err)
+	}
+}
+
+// removeLegacyTagPages removes the tag pages that older versions of til wrote
+// into the docs directory itself, as '<tag>.md'. They have no front-matter,
+// and start with a heading that is the name of the file
+func removeLegacyTagPages(tDir string) {
+	for _, filePath := range pageFilePaths(tDir) {
+		name := strings.TrimSuffix(filepath.Base(filePath), "."+pages.FileExtension)
+
+		switch name {
+		case "index", tagIndexName, untaggedName:
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		if !strings.HasPrefix(string(data), fmt.Sprintf("## %s\n", name)) || !strings.Contains(string(data), "senorprogrammer/til") {
+			continue
+		}
+
+		err = os.Remove(filePath)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		src.Progress(fmt.Sprintf("removed %s, its tag page is now in '%s'", filePath, pages.TagDirectory))
+	}
+}
+
+// isCaseInsensitiveDir returns true if file names in the directory are case
+// insensitive, ie: on macOS and Windows, by default
+func isCaseInsensitiveDir(dir string) bool {
+	file, err := ioutil.TempFile(dir, "til-case-")
+	if err != nil {
+		return false
+	}
+
+	file.Close()
+	defer os.Remove(file.Name())
+
+	_, err = os.Stat(filepath.Join(dir, strings.ToUpper(filepath.Base(file.Name()))))
+
+	return err == nil
+}
+
+// commit records the staged changes as the configured committer, signing the
+// commit if a signing key is configured, and returns the resulting commit
+func commit(r *git.Repository, commitMsg string) *object.Commit {
+	w, err := r.Worktree()
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	defaultCommitName, defaultCommitEmail := committer(src.GlobalConfig)
+
+	// Load the key up front so that a broken key doesn't leave an unsigned commit behind
+	signingKey := loadSigningKey(src.GlobalConfig)
+
+	hash, err := w.Commit(commitMsg, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  defaultCommitName,
+			Email: defaultCommitEmail,
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	if signingKey != nil {
+		hash, err = repo.SignCommit(r, hash, signingKey)
+		if err != nil {
+			src.Defeat(err)
+		}
+	}
+
+	obj, err := r.CommitObject(hash)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	return obj
+}
+
+// committer returns the name and email address til commits as
+func committer(cfg *config.Config) (string, string) {
+	email, err := cfg.String("committerEmail")
+	if err != nil {
+		src.Defeat(errors.New(errConfigValueRead))
+	}
+
+	name, err := cfg.String("committerName")
+	if err != nil {
+		src.Defeat(errors.New(errConfigValueRead))
+	}
+
+	return name, email
+}
+
+// configurePages applies the page-related settings in the config to the pages package
+func configurePages(cfg *config.Config) {
+	aliases := map[string]string{}
+
+	aliasMap, err := cfg.Map("tagAliases")
+	if err == nil {
+		for alias, canonical := range aliasMap {
+			aliases[alias] = fmt.Sprintf("%v", canonical)
+		}
+	}
+
+	pages.Normaliser = pages.NewTagNormaliser(cfg.UBool("tagCaseFold", false), aliases)
+
+	separator := cfg.UString("slugSeparator", pages.DefaultSlugSeparator)
+	if !pages.IsValidSeparator(separator) {
+		src.Defeat(fmt.Errorf(errSlugSeparator, separator))
+	}
+
+	pages.Slugs = pages.NewSlugifier(separator, cfg.UInt("slugMaxLength", pages.DefaultSlugMaxLength))
+
+	titleCase := cfg.UString("titleCase", pages.TitleCaseTitle)
+	if !pages.IsValidTitleCase(titleCase) {
+		src.Defeat(fmt.Errorf(errTitleCase, titleCase))
+	}
+
+	titleWords := []string{}
+
+	wordList, err := cfg.List("titleWords")
+	if err == nil {
+		for _, word := range wordList {
+			titleWords = append(titleWords, fmt.Sprintf("%v", word))
+		}
+	}
+
+	pages.Titles = pages.NewTitleCaser(titleCase, titleWords)
+}
+
+// configuredTagDescriptions returns the tag descriptions from the
+// tagDescriptions map in the config, keyed by tag name
+func configuredTagDescriptions(cfg *config.Config) map[string]*pages.TagDescription {
+	descMap, err := cfg.Map("tagDescriptions")
+	if err != nil {
+		return map[string]*pages.TagDescription{}
+	}
+
+	descs, err := pages.TagDescriptionsFromConfig(descMap)
+	if err != nil {
+		src.Defeat(fmt.Errorf(errTagDescription, err.Error()))
+	}
+
+	return descs
+}
+
+// createNewPage creates a page with the given title from the chosen template,
+// adds the tags, body, and front-matter it was given, and opens it in the
+// editor if asked to
+func createNewPage(title string, opts *newPageOptions) {
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, true)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	tmpl := chooseTemplate(filepath.Dir(tDir), opts.templateName, opts.tagNames)
+
+	// The body used up stdin, leaving nothing to answer the template's questions
+	if opts.bodyOnStdin && tmpl != nil && tmpl.Prompts() {
+		src.Defeat(fmt.Errorf(errStdinPrompts, tmpl.Name))
+	}
+
+	page, err := pages.NewPageFromTemplate(title, opts.tagNames, tDir, tmpl, prompt)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	if opts.body != "" {
+		page.AddContent(opts.body)
+	}
+
+	if opts.body != "" || len(opts.frontMatter) > 0 {
+		for _, item := range opts.frontMatter {
+			page.SetExtra(fmt.Sprintf("%v", item.Key), item.Value)
+		}
+
+		page.Save()
+	}
+
+	if !opts.openEditor {
+		src.Info(page.FilePath)
+		return
+	}
 
 	err = page.Open(defaultEditor)
 	if err != nil {
 		src.Defeat(err)
 	}
 
+	// A slug given in the front-matter while the page was open replaces the
+	// one made from the title. Nothing links to a new page yet, so the file
+	// can simply be renamed
+	edited, err := pages.Load(page.FilePath)
+	if err == nil && edited.CustomSlug != "" {
+		err = edited.Retitle(edited.Title)
+		if err != nil {
+			src.Defeat(err)
+		}
+
+		page = edited
+	}
+
 	// Write the page path to the console. This makes it easy to know which file we just created
 	src.Info(page.FilePath)
 }
@@ -249,6 +680,129 @@ func determineCommitMessage(cfg *config.Config, args []string) string {
 	return msg
 }
 
+// indexPagePath returns the path to the index page in the given directory
+func indexPagePath(tDir string) string {
+	return fmt.Sprintf(
+		"%s/index.%s",
+		tDir,
+		pages.FileExtension,
+	)
+}
+
+// indexTagList creates the list of tags at the top of the index, in the
+// given layout. If limit is more than zero, only that many of the most-used
+// tags are shown, followed by a link to the tag index page
+func indexTagList(tagMap *pages.TagMap, layout string, limit int) string {
+	content := ""
+
+	stats := tagMap.Stats()
+	shown := pages.TopTagStats(stats, limit)
+
+	switch layout {
+	case indexLayoutList:
+		// The tree only makes sense when every tag is in it
+		if tagMap.IsHierarchical() && len(shown) == len(stats) {
+			content += tagTree(tagMap, "", 0)
+			break
+		}
+
+		tagLinks := []string{}
+		for _, stat := range shown {
+			tagLinks = append(tagLinks, tagMap.Get(stat.Name).Link())
+		}
+
+		content += strings.Join(tagLinks, ", ")
+		content += "\n"
+
+	case indexLayoutCounts:
+		tagLinks := []string{}
+		for _, stat := range shown {
+			tagLinks = append(tagLinks, fmt.Sprintf("%s (%d)", tagMap.Get(stat.Name).Link(), stat.Count))
+		}
+
+		content += strings.Join(tagLinks, ", ")
+		content += "\n"
+
+	case indexLayoutCloud:
+		tagLinks := []string{}
+		for _, stat := range shown {
+			tagLinks = append(
+				tagLinks,
+				fmt.Sprintf(
+					"<a href=\"./%s\" title=\"%d %s\" style=\"font-size: %d%%\">%s</a>",
+					pages.TagPath(stat.Name),
+					stat.Count,
+					pluralize(stat.Count, "page"),
+					100+(stat.Weight-1)*25,
+					html.EscapeString(stat.Name),
+				),
+			)
+		}
+
+		content += strings.Join(tagLinks, " ")
+		content += "\n"
+
+	case indexLayoutAlphabetical:
+		groups := map[string][]string{}
+		letters := []string{}
+
+		for _, stat := range shown {
+			letter := tagInitial(stat.Name)
+			if _, ok := groups[letter]; !ok {
+				letters = append(letters, letter)
+			}
+
+			groups[letter] = append(groups[letter], tagMap.Get(stat.Name).Link())
+		}
+
+		// The tags are in byte order, which puts every upper-case letter first
+		sort.Strings(letters)
+
+		for _, letter := range letters {
+			content += fmt.Sprintf("**%s** %s\n\n", letter, strings.Join(groups[letter], ", "))
+		}
+
+	default:
+		src.Defeat(fmt.Errorf(errIndexLayout, layout))
+	}
+
+	if len(shown) < len(stats) {
+		content += fmt.Sprintf("\n[All %d tags](./%s.%s)\n", len(stats), tagIndexName, pages.FileExtension)
+	}
+
+	return content
+}
+
+// isTitleMarked returns true if the arguments that follow the flags were
+// preceded by "--", which marks them all as the title of a new page
+func isTitleMarked(args []string) bool {
+	idx := len(args) - flag.NArg() - 1
+
+	return idx > 0 && args[idx] == "--"
+}
+
+// rejectArgs stops a sub-command that takes no arguments if it was given
+// some. They're more likely the rest of a title that starts with the
+// sub-command's name, ie: til undo a git rebase
+func rejectArgs(flags *flag.FlagSet) {
+	if flags.NArg() > 0 {
+		src.Defeat(fmt.Errorf(errNoArgs, flags.Name(), flags.Name(), strings.Join(flags.Args(), " ")))
+	}
+}
+
+// listedPages returns the content pages in the set that belong in listings
+func listedPages(pageSet []*pages.Page) []*pages.Page {
+	listed := []*pages.Page{}
+
+	for _, page := range pageSet {
+		if page.IsContentPage() && page.IsListed() {
+			listed = append(listed, page)
+		}
+	}
+
+	return listed
+}
+
 // listTargetDirectories writes the list of target directories in the configuration
 // out to the terminal
 func listTargetDirectories(cfg *config.Config) {
@@ -262,8 +816,40 @@ func listTargetDirectories(cfg *config.Config) {
 	}
 }
 
+// loadSigningKey returns the commit signing key defined in the config, or nil
+// if commit signing is not configured. The key's passphrase is read from the
+// TIL_SIGNING_PASSPHRASE environment variable, falling back to the config
+func loadSigningKey(cfg *config.Config) repo.SigningKey {
+	keyPath := cfg.UString("signingKey", "")
+	if keyPath == "" {
+		return nil
+	}
+
+	keyPath, err := src.ExpandPath(keyPath)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	passphrase := os.Getenv("TIL_SIGNING_PASSPHRASE")
+	if passphrase == "" {
+		passphrase = cfg.UString("signingPassphrase", "")
+	}
+
+	key, err := repo.LoadSigningKey(
+		cfg.UString("signingFormat", repo.SigningFormatOpenPGP),
+		keyPath,
+		passphrase,
+	)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	return key
+}
+
 // loadPages reads the page files from disk (in reverse chronological order) and
-// creates Page instances from them
+// creates Page instances from them. Every page that can't be parsed is
+// reported before giving up
 func loadPages() []*pages.Page {
 	pageSet := []*pages.Page{}
 
@@ -272,22 +858,81 @@ func loadPages() []*pages.Page {
 		src.Defeat(err)
 	}
 
-	filePaths, _ := filepath.Glob(
-		fmt.Sprintf(
-			"%s/*.%s",
-			tDir,
-			pages.FileExtension,
-		),
-	)
+	filePaths := pageFilePaths(tDir)
+	badPages := 0
 
 	for i := len(filePaths) - 1; i >= 0; i-- {
-		page := pages.PageFromFilePath(filePaths[i])
+		page, err := pages.Load(filePaths[i])
+		if err != nil {
+			src.Progress(src.Red(err.Error()))
+			badPages++
+			continue
+		}
+
 		pageSet = append(pageSet, page)
 	}
 
+	if badPages > 0 {
+		src.Defeat(fmt.Errorf(errUnparsablePages, badPages))
+	}
+
+	return pageSet
+}
+
+// loadPagesWithHistory loads the pages like loadPages does, along with each
+// page's git history. Walking the history is slow in big targets, so only
+// what shows when a page was updated needs it
+func loadPagesWithHistory() []*pages.Page {
+	pageSet := loadPages()
+	loadRevisions(pageSet)
+
 	return pageSet
 }
 
+// loadRevisions fills in each page's git history. Targets that are not git
+// repositories are left alone, their pages simply have no history
+func loadRevisions(pageSet []*pages.Page) {
+	root, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	r, err := git.PlainOpen(root)
+	if err != nil {
+		return
+	}
+
+	history, err := repo.FileHistory(r, "docs/", isRenamedPage)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	for _, page := range pageSet {
+		relPath, err := filepath.Rel(root, page.FilePath)
+		if err != nil {
+			continue
+		}
+
+		for _, c := range history[filepath.ToSlash(relPath)] {
+			page.Revisions = append(page.Revisions, &pages.Revision{
+				Author:  c.Author.Name,
+				Email:   c.Author.Email,
+				Hash:    c.Hash.String(),
+				Message: c.Message,
+				When:    c.Author.When,
+			})
+		}
+	}
+}
+
+// isRenamedPage returns true if the page file at the from path became the one
+// at the to path. Renaming a page keeps the timestamp its file name starts with
+func isRenamedPage(from string, to string) bool {
+	prefix := pages.TimestampPrefix(from)
+
+	return prefix != "" && prefix == pages.TimestampPrefix(to)
+}
+
 // // open tll the OS to open the newly-created page in the editor (as specified in the config)
 // // If there's no editor explicitly defined by the user, tell the OS to try and open it
 // func open(page *src.Page) error {
@@ -302,14 +947,31 @@ func loadPages() []*pages.Page {
 // 	return err
 // }
 
+// openTargetRepo opens the git repository that lives in the target directory
+func openTargetRepo() *git.Repository {
+	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	r, err := git.PlainOpen(tDir)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	return r
+}
+
 // pagesToHTMLUnorderedList creates the unordered list of page links that appear
-// on the index and tag pages
-func pagesToHTMLUnorderedList(pageSet []*pages.Page) string {
+// on the index and tag pages. Pages that aren't listed are left out. rootPath
+// is the relative path from the page the list appears on back up to the docs
+// directory, and is empty for pages that live in the docs directory
+func pagesToHTMLUnorderedList(pageSet []*pages.Page, rootPath string) string {
 	content := ""
 	prevPage := &pages.Page{}
 
 	for _, page := range pageSet {
-		if !page.IsContentPage() {
+		if !page.IsContentPage() || !page.IsListed() {
 			continue
 		}
 
@@ -318,7 +980,7 @@ func pagesToHTMLUnorderedList(pageSet []*pages.Page) string {
 			content += "\n"
 		}
 
-		content += fmt.Sprintf("* %s\n", page.Link())
+		content += fmt.Sprintf("* %s\n", page.RelativeLink(rootPath))
 
 		prevPage = page
 	}
@@ -326,80 +988,188 @@ func pagesToHTMLUnorderedList(pageSet []*pages.Page) string {
 	return content
 }
 
+// pagesExcept returns the pages in the set that are not in the excluded set
+func pagesExcept(pageSet []*pages.Page, excluded []*pages.Page) []*pages.Page {
+	skip := make(map[*pages.Page]bool, len(excluded))
+	for _, page := range excluded {
+		skip[page] = true
+	}
+
+	remaining := []*pages.Page{}
+	for _, page := range pageSet {
+		if !skip[page] {
+			remaining = append(remaining, page)
+		}
+	}
+
+	return remaining
+}
+
+// pageFilePaths returns the paths to every page file in the directory, in
+// alphabetical (and therefore chronological) order
+func pageFilePaths(tDir string) []string {
+	filePaths, _ := filepath.Glob(
+		fmt.Sprintf(
+			"%s/*.%s",
+			tDir,
+			pages.FileExtension,
+		),
+	)
+
+	return filePaths
+}
+
 func parseTitle(targetFlag string, args []string) string {
 	titleOffset := 3
 	if targetFlag == "" {
 		titleOffset = 1
 	}
 
-	return strings.Title(strings.Join(args[titleOffset:], " "))
+	words := args[titleOffset:]
+
+	// The "--" that marks the start of a title isn't part of it
+	if len(words) > 0 && words[0] == "--" {
+		words = words[1:]
+	}
+
+	return pages.Titles.Case(strings.Join(words, " "))
 }
 
-// push pushes up to the remote git repo
-func push() {
-	src.Info(statusRepoPush)
+// pinnedPages finds the pages that the tag description pins to the top of
+// the tag's page, in the order they were pinned
+func pinnedPages(pageSet []*pages.Page, tagName string, desc *pages.TagDescription) []*pages.Page {
+	pinned := []*pages.Page{}
 
-	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
-	if err != nil {
-		src.Defeat(err)
+	for _, ref := range desc.Pinned {
+		page, err := pages.Find(pageSet, ref)
+		if err != nil {
+			src.Defeat(fmt.Errorf(errTagPinned, tagName, err.Error()))
+		}
+
+		// Drafts and the like stay hidden until they're published
+		if page.IsListed() {
+			pinned = append(pinned, page)
+		}
 	}
 
-	r, err := git.PlainOpen(tDir)
+	return pinned
+}
+
+// tagDescription returns the hand-written content for the tag's page. A tag
+// description file in the target's tags directory takes precedence over the
+// description in the config, field by field
+func tagDescription(root string, tagName string, configured map[string]*pages.TagDescription) *pages.TagDescription {
+	fromFile, err := pages.LoadTagDescription(tagPagePath(root, tagName))
 	if err != nil {
 		src.Defeat(err)
 	}
 
-	err = r.Push(&git.PushOptions{})
-	if err != nil {
-		src.Defeat(err)
+	desc := fromFile.Merge(configured[tagName])
+	if desc == nil {
+		return &pages.TagDescription{}
 	}
+
+	return desc
 }
 
-// https://github.com/go-git/go-git/blob/master/_examples/commit/main.go
-func save(commitMsg string) {
-	src.Info(statusRepoSave)
+// tagIndexPagePath returns the path to the page that lists every tag
+func tagIndexPagePath(tDir string) string {
+	return fmt.Sprintf(
+		"%s/%s.%s",
+		tDir,
+		tagIndexName,
+		pages.FileExtension,
+	)
+}
 
-	tDir, err := src.GetTargetDir(src.GlobalConfig, targetDirFlag, false)
-	if err != nil {
-		src.Defeat(err)
+// tagInitial returns the upper-cased first letter of the tag name, or "#"
+// for tags that start with anything other than a letter
+func tagInitial(tagName string) string {
+	r, _ := utf8.DecodeRuneInString(tagName)
+	if unicode.IsLetter(r) {
+		return string(unicode.ToUpper(r))
 	}
 
-	r, err := git.PlainOpen(tDir)
-	if err != nil {
-		src.Defeat(err)
+	return "#"
+}
+
+// tagPagePath returns the path to the page for the given tag in the given directory
+func tagPagePath(tDir string, tagName string) string {
+	return fmt.Sprintf(
+		"%s/%s.%s",
+		tDir,
+		pages.TagPath(tagName),
+		pages.FileExtension,
+	)
+}
+
+// tagTree creates a nested unordered list of links to the tags below the
+// given parent tag (or, for an empty parent, to every tag)
+func tagTree(tagMap *pages.TagMap, parentName string, depth int) string {
+	content := ""
+
+	for _, childName := range tagMap.Children(parentName) {
+		child := pages.NewTag(childName, nil)
+
+		content += fmt.Sprintf("%s* %s\n", strings.Repeat("  ", depth), child.RelativeLink("", child.ShortName()))
+		content += tagTree(tagMap, childName, depth+1)
 	}
 
-	w, err := r.Worktree()
-	if err != nil {
-		src.Defeat(err)
+	return content
+}
+
+// untaggedPagePath returns the path to the page that lists the untagged pages
+func untaggedPagePath(tDir string) string {
+	return fmt.Sprintf(
+		"%s/%s.%s",
+		tDir,
+		untaggedName,
+		pages.FileExtension,
+	)
+}
+
+// prompt asks the question and returns the answer, without the whitespace
+// around it. Running out of input is treated as an empty answer
+func prompt(question string) string {
+	fmt.Printf("%s %s ", src.Green("?"), question)
+
+	answer, err := stdin.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
 	}
 
-	_, err = w.Add(".")
+	return strings.TrimSpace(answer)
+}
+
+// push pushes up to the remote git repo
+func push() {
+	src.Info(statusRepoPush)
+
+	r := openTargetRepo()
+
+	err := r.Push(&git.PushOptions{})
 	if err != nil {
 		src.Defeat(err)
 	}
+}
 
-	defaultCommitEmail, err2 := src.GlobalConfig.String("committerEmail")
-	defaultCommitName, err3 := src.GlobalConfig.String("committerName")
-	if err2 != nil || err3 != nil {
-		src.Defeat(errors.New(errConfigValueRead))
-	}
+// https://github.com/go-git/go-git/blob/master/_examples/commit/main.go
+func save(commitMsg string) {
+	src.Info(statusRepoSave)
 
-	commit, err := w.Commit(commitMsg, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  defaultCommitName,
-			Email: defaultCommitEmail,
-			When:  time.Now(),
-		},
-	})
+	r := openTargetRepo()
+
+	w, err := r.Worktree()
 	if err != nil {
 		src.Defeat(err)
 	}
 
-	obj, err := r.CommitObject(commit)
+	err = repo.AddAll(w)
 	if err != nil {
 		src.Defeat(err)
 	}
 
+	obj := commit(r, commitMsg)
+
 	src.Info(fmt.Sprintf("committed with '%s' (%.7s)", obj.Message, obj.Hash.String()))
 }
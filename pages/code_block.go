@@ -0,0 +1,19 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CodeBlock returns the text as a fenced Markdown code block in the given
+// language ("" for none). The fence is made longer than any run of backticks
+// in the text, so that text with fences of its own in it can't end the block early
+func CodeBlock(lang string, text string) string {
+	fence := "```"
+
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+
+	return fmt.Sprintf("%s%s\n%s\n%s\n", fence, lang, strings.TrimRight(text, "\n"), fence)
+}
@@ -0,0 +1,51 @@
+package pages
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Find returns the content page in the set that the given reference refers
+// to. A reference can be a path to the page, its file name (with or
+// without the extension), or its slug
+func Find(pageSet []*Page, ref string) (*Page, error) {
+	// A path to the page only needs to match on the file name
+	if strings.ContainsRune(ref, filepath.Separator) {
+		ref = filepath.Base(ref)
+	}
+
+	matches := []*Page{}
+
+	for _, page := range pageSet {
+		if page.IsContentPage() && page.matches(ref) {
+			matches = append(matches, page)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no page found for '%s'", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		names := []string{}
+		for _, page := range matches {
+			names = append(names, filepath.Base(page.FilePath))
+		}
+
+		return nil, fmt.Errorf("'%s' matches more than one page: %s", ref, strings.Join(names, ", "))
+	}
+}
+
+// matches returns true if the reference refers to this page
+func (page *Page) matches(ref string) bool {
+	base := filepath.Base(page.FilePath)
+
+	switch ref {
+	case base, strings.TrimSuffix(base, "."+FileExtension), page.Slug():
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,198 @@
+package pages
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ericaro/frontmatter"
+	"gopkg.in/yaml.v2"
+)
+
+const frontMatterDelim = "---"
+
+var (
+	errNoFrontMatter = errors.New("page has no front-matter")
+	errUnclosed      = errors.New("front-matter is never closed with '---'")
+
+	// Matches the line number in the errors yaml.v2 returns, ie: "yaml: line 3: did not find expected key"
+	yamlErrLineRegex = regexp.MustCompile(`line (\d+): (.*)`)
+
+	// Matches a top-level "key: value" line in front-matter
+	frontMatterKeyRegex = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.*)$`)
+)
+
+// ParseError describes a page whose front-matter could not be read
+type ParseError struct {
+	FilePath string
+	Line     int
+	Msg      string
+}
+
+// Error returns the error in the conventional file:line: message format
+func (pErr *ParseError) Error() string {
+	if pErr.Line == 0 {
+		return fmt.Sprintf("%s: %s", pErr.FilePath, pErr.Msg)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", pErr.FilePath, pErr.Line, pErr.Msg)
+}
+
+// Load reads the page file at filePath. If the front-matter can't be parsed
+// the error is a *ParseError pointing at the offending line
+func Load(filePath string) (*Page, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	page := new(Page)
+
+	err = frontmatter.Unmarshal(data, page)
+	if err != nil {
+		return nil, newParseError(filePath, err)
+	}
+
+	page.FilePath = filePath
+
+	return page, nil
+}
+
+// Repair makes a best-effort attempt at recovering the page from a file whose
+// front-matter is not valid YAML, usually because a value was written without
+// the quoting it needed. Each top-level key is parsed on its own, and values
+// that still won't parse are kept as plain strings. The repaired page is
+// returned, not saved
+func Repair(filePath string) (*Page, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fmLines, content, err := splitFrontMatter(data)
+	if err != nil {
+		return nil, &ParseError{FilePath: filePath, Line: 1, Msg: err.Error()}
+	}
+
+	fields := yaml.MapSlice{}
+
+	entries, entryLines := groupFrontMatterEntries(fmLines)
+
+	for i, entry := range entries {
+		item, ok := repairEntry(entry)
+		if !ok {
+			return nil, &ParseError{FilePath: filePath, Line: entryLines[i], Msg: fmt.Sprintf("cannot make sense of '%s'", entry)}
+		}
+
+		fields = append(fields, item)
+	}
+
+	fmData, err := yaml.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	page := new(Page)
+
+	err = yaml.Unmarshal(fmData, page)
+	if err != nil {
+		return nil, newParseError(filePath, err)
+	}
+
+	page.Content = content
+	page.FilePath = filePath
+
+	return page, nil
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// newParseError turns an error from the front-matter parser into a
+// ParseError, translating yaml's line numbers into line numbers in the file
+func newParseError(filePath string, err error) *ParseError {
+	if err == frontmatter.ErrMissingSeparator {
+		return &ParseError{FilePath: filePath, Line: 1, Msg: errUnclosed.Error()}
+	}
+
+	match := yamlErrLineRegex.FindStringSubmatch(err.Error())
+	if match == nil {
+		return &ParseError{FilePath: filePath, Msg: err.Error()}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+
+	// The front-matter starts on the line after the opening delimiter
+	return &ParseError{FilePath: filePath, Line: line + 1, Msg: match[2]}
+}
+
+// splitFrontMatter separates the lines of front-matter from the page content
+func splitFrontMatter(data []byte) ([]string, string, error) {
+	lines := strings.SplitAfter(string(data), "\n")
+
+	if strings.TrimSpace(lines[0]) != frontMatterDelim {
+		return nil, "", errNoFrontMatter
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != frontMatterDelim {
+			continue
+		}
+
+		fmLines := []string{}
+		for _, line := range lines[1:i] {
+			fmLines = append(fmLines, strings.TrimRight(line, "\r\n"))
+		}
+
+		return fmLines, strings.Join(lines[i+1:], ""), nil
+	}
+
+	return nil, "", errUnclosed
+}
+
+// groupFrontMatterEntries groups the front-matter lines into one entry per
+// top-level key, so that indented continuation lines (ie: block sequences)
+// stay with the key they belong to. It also returns the line of the file that
+// each entry starts on
+func groupFrontMatterEntries(lines []string) ([]string, []int) {
+	entries := []string{}
+	entryLines := []int{}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if len(entries) > 0 && !frontMatterKeyRegex.MatchString(line) {
+			entries[len(entries)-1] += "\n" + line
+			continue
+		}
+
+		// The front-matter starts on the line after the opening delimiter
+		entries = append(entries, line)
+		entryLines = append(entryLines, i+2)
+	}
+
+	return entries, entryLines
+}
+
+// repairEntry parses a single front-matter entry, falling back to treating
+// everything after the key as a plain string. It returns false if the entry
+// doesn't even start with a key
+func repairEntry(entry string) (yaml.MapItem, bool) {
+	parsed := yaml.MapSlice{}
+
+	err := yaml.Unmarshal([]byte(entry), &parsed)
+	if err == nil && len(parsed) == 1 {
+		return parsed[0], true
+	}
+
+	match := frontMatterKeyRegex.FindStringSubmatch(strings.SplitN(entry, "\n", 2)[0])
+	if match == nil {
+		return yaml.MapItem{}, false
+	}
+
+	return yaml.MapItem{Key: match[1], Value: strings.TrimSpace(match[2])}, true
+}
@@ -0,0 +1,127 @@
+package pages
+
+import (
+	"sort"
+	"strings"
+)
+
+// Scores for the ways a query can match a title, best first. Within each,
+// matches nearer the start of the title (or with fewer gaps) score higher
+const (
+	fuzzyExact       = 1000
+	fuzzyPrefix      = 800
+	fuzzySubstring   = 600
+	fuzzyWords       = 400
+	fuzzySubsequence = 200
+)
+
+// FuzzyFind returns the content pages whose titles match the query, best
+// match first. Pages that match equally well are newest first. A title
+// matches if it contains the query, contains every word of the query, or
+// contains the letters of the query in order (ie: "gtst" matches "Go
+// testing")
+func FuzzyFind(pageSet []*Page, query string) []*Page {
+	scores := map[*Page]int{}
+	matches := []*Page{}
+
+	for _, page := range pageSet {
+		if !page.IsContentPage() {
+			continue
+		}
+
+		score := fuzzyScore(page.Title, query)
+		if slugScore := fuzzyScore(strings.ReplaceAll(page.Slug(), "-", " "), query); slugScore > score {
+			score = slugScore
+		}
+
+		if score > 0 {
+			scores[page] = score
+			matches = append(matches, page)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if scores[matches[i]] != scores[matches[j]] {
+			return scores[matches[i]] > scores[matches[j]]
+		}
+
+		return matches[i].CreatedAt().After(matches[j].CreatedAt())
+	})
+
+	return matches
+}
+
+// fuzzyScore returns how well the query matches the title, ignoring case.
+// Zero means it doesn't match at all
+func fuzzyScore(title string, query string) int {
+	title = strings.ToLower(strings.TrimSpace(title))
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	if title == "" || query == "" {
+		return 0
+	}
+
+	if title == query {
+		return fuzzyExact
+	}
+
+	if strings.HasPrefix(title, query) {
+		return fuzzyPrefix
+	}
+
+	if idx := strings.Index(title, query); idx >= 0 {
+		return fuzzySubstring - minInt(idx, fuzzySubstring-fuzzyWords-1)
+	}
+
+	if containsAllWords(title, strings.Fields(query)) {
+		return fuzzyWords
+	}
+
+	if gaps, ok := subsequenceGaps([]rune(title), []rune(query)); ok {
+		return fuzzySubsequence - minInt(gaps, fuzzySubsequence-1)
+	}
+
+	return 0
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// containsAllWords returns true if every one of the words appears in the title
+func containsAllWords(title string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(title, word) {
+			return false
+		}
+	}
+
+	return len(words) > 0
+}
+
+// subsequenceGaps returns true if the letters of query appear in title in
+// order, along with how many letters of the title had to be skipped between
+// the first and last of them
+func subsequenceGaps(title []rune, query []rune) (int, bool) {
+	qIdx := 0
+	start, gaps := -1, 0
+
+	for tIdx, r := range title {
+		if qIdx == len(query) {
+			break
+		}
+
+		if r == query[qIdx] {
+			if start < 0 {
+				start = tIdx
+			}
+
+			qIdx++
+			continue
+		}
+
+		if start >= 0 {
+			gaps++
+		}
+	}
+
+	return gaps, qIdx == len(query)
+}
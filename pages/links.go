@@ -0,0 +1,43 @@
+package pages
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// linkTargetRegex matches inline Markdown links, ie: [text](2020-04-20T14-52-57-old-title.md#anchor),
+// and reference-style link definitions, ie: [1]: ./2020-04-20T14-52-57-old-title.html,
+// whose target is the file with the given name (without its extension)
+const linkTargetRegex = `(?m)(\]\((?:[^)\s]*/)?|^[ \t]*\[[^\]]+\]:[ \t]*(?:\S*/)?)%s(\.%s|\.html)?([#?][^)\s]*)?([ \t]+"[^"]*")?(\)|[ \t]*$)`
+
+// HasLinkTo returns true if the content links to the page file with the
+// given name (without its extension)
+func HasLinkTo(content string, name string) bool {
+	return linkRegex(name).MatchString(content)
+}
+
+// RewriteLinks changes every link in the content to the page file named
+// oldName so that it points at the one named newName instead (both without
+// their extensions). The rest of each link is left as it was. It returns the
+// new content and how many links were changed
+func RewriteLinks(content string, oldName string, newName string) (string, int) {
+	rgx := linkRegex(oldName)
+	count := len(rgx.FindAllStringIndex(content, -1))
+
+	if count == 0 {
+		return content, 0
+	}
+
+	return rgx.ReplaceAllString(content, "${1}"+escapeReplacement(newName)+"${2}${3}${4}${5}"), count
+}
+
+/* -------------------- Helper functions -------------------- */
+
+func linkRegex(name string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(linkTargetRegex, regexp.QuoteMeta(name), FileExtension))
+}
+
+// escapeReplacement stops any '$' in the text being read as a group reference
+func escapeReplacement(text string) string {
+	return regexp.MustCompile(`\$`).ReplaceAllString(text, "$$$$")
+}
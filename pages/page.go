@@ -3,13 +3,15 @@ package pages
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/ericaro/frontmatter"
 	"github.com/senorprogrammer/til/src"
+	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -18,15 +20,52 @@ const (
 
 	// FileExtension defines the extension to write on the generated file
 	FileExtension = "md"
+
+	defaultLayout = "default"
 )
 
+// knownFrontMatterKeys are the front-matter keys that map onto Page fields.
+// Any other keys found in a page's front-matter end up in Page.Extra
+var knownFrontMatterKeys = map[string]bool{
+	"aliases":  true,
+	"archived": true,
+	"date":     true,
+	"draft":    true,
+	"filepath": true,
+	"layout":   true,
+	"series":   true,
+	"slug":     true,
+	"summary":  true,
+	"tags":     true,
+	"title":    true,
+	"unlisted": true,
+	"updated":  true,
+}
+
 // Page represents a TIL page
 type Page struct {
-	Content  string `fm:"content" yaml:"-"`
-	Date     string `yaml:"date"`
-	FilePath string `yaml:"filepath"`
-	TagsStr  string `yaml:"tags"`
-	Title    string `yaml:"title"`
+	Aliases    []string `yaml:"aliases"`
+	Archived   bool     `yaml:"archived"`
+	Content    string   `fm:"content" yaml:"-"`
+	CustomSlug string   `yaml:"slug"`
+	Date       string   `yaml:"date"`
+	Draft      bool     `yaml:"draft"`
+	FilePath   string   `yaml:"filepath"`
+	Layout     string   `yaml:"layout"`
+	Series     string   `yaml:"series"`
+	Summary    string   `yaml:"summary"`
+	TagNames   TagList  `yaml:"tags"`
+	Title      string   `yaml:"title"`
+	Unlisted   bool     `yaml:"unlisted"`
+	Updated    string   `yaml:"updated"`
+
+	// Extra holds any front-matter keys til doesn't know about, in the order
+	// they appeared, so that saving the page doesn't drop them
+	Extra yaml.MapSlice `yaml:"-"`
+
+	// Revisions is the page's git history, newest first. It is empty if the
+	// page has never been committed
+	Revisions []*Revision `yaml:"-"`
 }
 
 // NewPage creates and returns an instance of page
@@ -34,15 +73,10 @@ func NewPage(title string, targetDir string) *Page {
 	date := time.Now()
 
 	page := &Page{
-		Date: date.Format(time.RFC3339),
-		FilePath: fmt.Sprintf(
-			"%s/%s-%s.%s",
-			targetDir,
-			date.Format(ghFriendlyDateFormat),
-			strings.ReplaceAll(strings.ToLower(title), " ", "-"),
-			FileExtension,
-		),
-		Title: title,
+		Date:     date.Format(time.RFC3339),
+		Layout:   defaultLayout,
+		FilePath: uniqueFilePath(targetDir, date.Format(ghFriendlyDateFormat), Slugs.Slugify(title), ""),
+		Title:    title,
 	}
 
 	page.Save()
@@ -52,21 +86,32 @@ func NewPage(title string, targetDir string) *Page {
 
 // PageFromFilePath creates and returns a Page instance from a file path
 func PageFromFilePath(filePath string) *Page {
-	page := new(Page)
-
-	data, err := ioutil.ReadFile(filePath)
+	page, err := Load(filePath)
 	if err != nil {
 		src.Defeat(err)
 	}
 
-	err = frontmatter.Unmarshal(data, page)
-	if err != nil {
-		src.Defeat(err)
+	return page
+}
+
+// AddContent adds the text to the end of the page's content. A page with no
+// content yet gets its heading first
+func (page *Page) AddContent(text string) {
+	content := strings.TrimRight(page.Content, "\n")
+	if strings.TrimSpace(content) == "" {
+		content = fmt.Sprintf("# %s", page.Title)
 	}
 
-	page.FilePath = filePath
+	page.Content = content + "\n\n" + strings.Trim(text, "\n") + "\n"
+}
 
-	return page
+// Author returns the name of whoever first committed the page, if known
+func (page *Page) Author() string {
+	if len(page.Revisions) == 0 {
+		return ""
+	}
+
+	return page.Revisions[len(page.Revisions)-1].Author
 }
 
 // CreatedAt returns a time instance representing when the page was created
@@ -88,14 +133,59 @@ func (page *Page) CreatedMonth() time.Month {
 	return page.CreatedAt().Month()
 }
 
-// FrontMatter returns the front-matter of the page
+// FirstCommittedAt returns when the page was first committed, or the zero
+// time if it never has been
+func (page *Page) FirstCommittedAt() time.Time {
+	if len(page.Revisions) == 0 {
+		return time.Time{}
+	}
+
+	return page.Revisions[len(page.Revisions)-1].When
+}
+
+// FrontMatter returns the front-matter of the page, serialised as YAML
 func (page *Page) FrontMatter() string {
-	return fmt.Sprintf(
-		"---\nlayout: default\ndate: %s\ntitle: %s\ntags: %s\n---\n\n",
-		page.Date,
-		page.Title,
-		page.TagsStr,
-	)
+	layout := page.Layout
+	if layout == "" {
+		layout = defaultLayout
+	}
+
+	tags := page.TagNames
+	if tags == nil {
+		tags = TagList{}
+	}
+
+	fields := yaml.MapSlice{
+		{Key: "layout", Value: layout},
+		{Key: "date", Value: page.Date},
+		{Key: "title", Value: page.Title},
+		{Key: "tags", Value: []string(tags)},
+	}
+
+	// The optional fields are only written out when they have values
+	for _, item := range []yaml.MapItem{
+		{Key: "updated", Value: page.Updated},
+		{Key: "draft", Value: page.Draft},
+		{Key: "unlisted", Value: page.Unlisted},
+		{Key: "archived", Value: page.Archived},
+		{Key: "summary", Value: page.Summary},
+		{Key: "aliases", Value: page.Aliases},
+		{Key: "slug", Value: page.CustomSlug},
+		{Key: "series", Value: page.Series},
+	} {
+		if !isEmptyValue(item.Value) {
+			fields = append(fields, item)
+		}
+	}
+
+	fields = append(fields, page.Extra...)
+
+	data, err := yaml.Marshal(fields)
+	if err != nil {
+		src.Defeat(err)
+	}
+
+	return "---\n" + string(data) + "---\n\n"
 }
 
 // IsContentPage returns true if the page is a valid entry page, false if it is not
@@ -103,18 +193,77 @@ func (page *Page) IsContentPage() bool {
 	return page.Title != ""
 }
 
+// IsListed returns true if the page belongs in the index, tag pages, and
+// feeds. Drafts, pages scheduled for the future, and unlisted and archived
+// pages do not
+func (page *Page) IsListed() bool {
+	return !page.Draft && !page.Unlisted && !page.Archived && !page.IsScheduled()
+}
+
+// IsPending returns true if the page is a draft or is scheduled for the future
+func (page *Page) IsPending() bool {
+	return page.Draft || page.IsScheduled()
+}
+
+// IsScheduled returns true if the page's date is still in the future. It will
+// be listed by the first build that runs after that date
+func (page *Page) IsScheduled() bool {
+	return page.CreatedAt().After(time.Now())
+}
+
+// IsUpdated returns true if the page was last modified on a later day than
+// the one it was created on
+func (page *Page) IsUpdated() bool {
+	created := page.CreatedAt()
+	modified := page.LastModifiedAt()
+
+	if created.IsZero() || modified.IsZero() {
+		return false
+	}
+
+	return modified.Format("2006-01-02") > created.Format("2006-01-02")
+}
+
+// LastModifiedAt returns when the page was last changed, going by the later
+// of its last commit and its updated front-matter field. It returns the zero
+// time if neither is known
+func (page *Page) LastModifiedAt() time.Time {
+	modified := page.UpdatedAt()
+
+	if len(page.Revisions) > 0 && page.Revisions[0].When.After(modified) {
+		modified = page.Revisions[0].When
+	}
+
+	return modified
+}
+
 // Link returns a link string suitable for embedding in a Markdown page
 func (page *Page) Link() string {
-	return fmt.Sprintf(
-		"<code>%s</code> [%s](%s)",
+	return page.RelativeLink("")
+}
+
+// RelativeLink returns a link string suitable for embedding in a Markdown
+// page that lives rootPath away from the docs directory (ie: "../" for a
+// page one directory down)
+func (page *Page) RelativeLink(rootPath string) string {
+	link := fmt.Sprintf(
+		"<code>%s</code> [%s](%s%s)",
 		page.PrettyDate(),
 		page.Title,
+		rootPath,
 		filepath.Base(page.FilePath),
 	)
+
+	if page.IsUpdated() {
+		link += fmt.Sprintf(" <sub>updated %s</sub>", page.PrettyUpdatedDate())
+	}
+
+	return link
 }
 
 // Open tll the OS to open the newly-created page in the editor (as specified in the config)
-// If there's no editor explicitly defined by the user, tell the OS to try and open it
+// If there's no editor explicitly defined by the user, tell the OS to try and open it.
+// The editor gets the terminal, so that editors like vim work
 func (page *Page) Open(defaultEditor string) error {
 	editor := src.GlobalConfig.UString("editor", defaultEditor)
 	if editor == "" {
@@ -122,6 +271,10 @@ func (page *Page) Open(defaultEditor string) error {
 	}
 
 	cmd := exec.Command(editor, page.FilePath)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
 	err := cmd.Run()
 
 	return err
@@ -132,10 +285,111 @@ func (page *Page) PrettyDate() string {
 	return page.CreatedAt().Format("Jan 02, 2006")
 }
 
-// Save writes the content of the page to file
+// PrettyUpdatedDate returns a human-friendly representation of the LastModifiedAt date
+func (page *Page) PrettyUpdatedDate() string {
+	return page.LastModifiedAt().Format("Jan 02, 2006")
+}
+
+// PublishState returns a short description of whether and how the page is published
+func (page *Page) PublishState() string {
+	switch {
+	case page.Draft:
+		return "draft"
+	case page.IsScheduled():
+		return fmt.Sprintf("scheduled for %s", page.PrettyDate())
+	case page.Unlisted:
+		return "unlisted"
+	case page.Archived:
+		return "archived"
+	default:
+		return "published"
+	}
+}
+
+// RenameTags replaces every tag name that normalises to one of the keys of
+// renames with the matching value. Child tags of a renamed tag are moved along
+// with it (ie: renaming "lang" to "languages" turns "lang/go" into
+// "languages/go"). It returns true if any of the page's tags changed
+func (page *Page) RenameTags(renames map[string]string) bool {
+	changed := false
+	names := TagList{}
+	seen := map[string]bool{}
+
+	for _, name := range page.TagNames {
+		normalised := Normaliser.Normalise(name)
+
+		for oldName, newName := range renames {
+			oldName = Normaliser.Normalise(oldName)
+
+			if normalised == oldName {
+				name = CleanTagName(newName)
+				changed = true
+				break
+			}
+
+			if strings.HasPrefix(normalised, oldName+TagSeparator) {
+				name = CleanTagName(newName) + strings.TrimPrefix(normalised, oldName)
+				changed = true
+				break
+			}
+		}
+
+		// Merging tags can leave the page with the same tag twice
+		if !seen[Normaliser.Normalise(name)] {
+			names = append(names, name)
+			seen[Normaliser.Normalise(name)] = true
+		}
+	}
+
+	page.TagNames = names
+
+	return changed
+}
+
+// Retitle changes the page's title, the heading at the top of its content if
+// that was the old title, and the file name to match, keeping the timestamp
+// at the start of it. A slug set in the front-matter is used for the file
+// name instead of the title. The page is saved under its new file name and
+// the old file is removed
+func (page *Page) Retitle(title string) error {
+	oldPath := page.FilePath
+
+	prefix := page.fileNamePrefix()
+	if prefix == "" {
+		prefix = page.CreatedAt().Format(ghFriendlyDateFormat)
+	}
+
+	newPath := uniqueFilePath(filepath.Dir(oldPath), prefix, page.slugFor(title), oldPath)
+
+	oldHeading := fmt.Sprintf("# %s\n", page.Title)
+	content := strings.TrimLeft(page.Content, "\n")
+
+	if strings.HasPrefix(content, oldHeading) {
+		page.Content = fmt.Sprintf("# %s\n", title) + strings.TrimPrefix(content, oldHeading)
+	}
+
+	page.Title = title
+	page.FilePath = newPath
+
+	page.Save()
+
+	if newPath != oldPath {
+		return os.Remove(oldPath)
+	}
+
+	return nil
+}
+
+// Save writes the page to file. Pages that don't have any content yet get a
+// heading made from the title
 func (page *Page) Save() {
 	pageSrc := page.FrontMatter()
-	pageSrc += fmt.Sprintf("# %s\n\n", page.Title)
+
+	if strings.TrimSpace(page.Content) == "" {
+		pageSrc += fmt.Sprintf("# %s\n\n", page.Title)
+	} else {
+		pageSrc += strings.TrimLeft(page.Content, "\n")
+	}
 
 	err := ioutil.WriteFile(page.FilePath, []byte(pageSrc), 0644)
 	if err != nil {
@@ -143,14 +397,185 @@ func (page *Page) Save() {
 	}
 }
 
-// Tags returns a slice of tags assigned to this page
+// SetExtra sets a front-matter key that til doesn't know about, replacing
+// its value if the page already has it
+func (page *Page) SetExtra(key string, value interface{}) {
+	for i, item := range page.Extra {
+		if item.Key == key {
+			page.Extra[i].Value = value
+			return
+		}
+	}
+
+	page.Extra = append(page.Extra, yaml.MapItem{Key: key, Value: value})
+}
+
+// SetUpdated records the time the page was last changed in its front-matter
+func (page *Page) SetUpdated(at time.Time) {
+	page.Updated = at.Format(time.RFC3339)
+}
+
+// Slug returns the part of the page's file name that is derived from its title
+func (page *Page) Slug() string {
+	name := strings.TrimSuffix(filepath.Base(page.FilePath), "."+FileExtension)
+
+	// Strip the timestamp prefix, if there is one
+	if prefix := page.fileNamePrefix(); prefix != "" {
+		return strings.TrimPrefix(name[len(prefix):], "-")
+	}
+
+	return name
+}
+
+// SlugPending returns true if the front-matter sets a slug that the page's
+// file name doesn't have yet
+func (page *Page) SlugPending() bool {
+	if strings.TrimSpace(page.CustomSlug) == "" {
+		return false
+	}
+
+	want := Slugs.Slugify(page.CustomSlug)
+	slug := page.Slug()
+
+	if slug == want {
+		return false
+	}
+
+	// A number added to keep the file name unique doesn't count
+	suffix := strings.TrimPrefix(slug, want+Slugs.Separator)
+	_, err := strconv.Atoi(suffix)
+
+	return suffix == slug || err != nil
+}
+
+// Tags returns a slice of tags assigned to this page, with their names
+// normalised by the package Normaliser. Blank names, and names repeated
+// after normalising, are skipped
 func (page *Page) Tags() []*Tag {
 	tags := []*Tag{}
+	seen := map[string]bool{}
+
+	for _, name := range page.TagNames {
+		name = Normaliser.Normalise(name)
+		if name == "" || seen[name] {
+			continue
+		}
 
-	names := strings.Split(page.TagsStr, ",")
-	for _, name := range names {
 		tags = append(tags, NewTag(name, page))
+		seen[name] = true
 	}
 
 	return tags
 }
+
+// UnmarshalYAML reads the page's front-matter, keeping hold of any keys that
+// don't map onto Page fields
+func (page *Page) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// rawPage has Page's fields but not its methods, which stops this from recursing
+	type rawPage Page
+
+	raw := rawPage{}
+	err := unmarshal(&raw)
+	if err != nil {
+		return err
+	}
+
+	all := yaml.MapSlice{}
+	err = unmarshal(&all)
+	if err != nil {
+		return err
+	}
+
+	*page = Page(raw)
+
+	for _, item := range all {
+		key, ok := item.Key.(string)
+		if !ok || !knownFrontMatterKeys[key] {
+			page.Extra = append(page.Extra, item)
+		}
+	}
+
+	return nil
+}
+
+// UpdatedAt returns a time instance representing the updated front-matter
+// field, or the zero time if it isn't set
+func (page *Page) UpdatedAt() time.Time {
+	date, err := time.Parse(time.RFC3339, page.Updated)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return date
+}
+
+// TimestampPrefix returns the timestamp at the start of a page file's name,
+// or "" if it doesn't start with one. Renaming a page keeps its timestamp,
+// so two page files with the same one are the same page
+func TimestampPrefix(filePath string) string {
+	name := strings.TrimSuffix(filepath.Base(filePath), "."+FileExtension)
+
+	if len(name) > len(ghFriendlyDateFormat) {
+		_, err := time.Parse(ghFriendlyDateFormat, name[:len(ghFriendlyDateFormat)])
+		if err == nil {
+			return name[:len(ghFriendlyDateFormat)]
+		}
+	}
+
+	return ""
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// isEmptyValue returns true for the zero values of the optional front-matter fields
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case []string:
+		return len(v) == 0
+	}
+
+	return value == nil
+}
+
+// fileNamePrefix returns the timestamp at the start of the page's file name,
+// or "" if it doesn't start with one
+func (page *Page) fileNamePrefix() string {
+	return TimestampPrefix(page.FilePath)
+}
+
+// slugFor returns the slug the page's file name should have if it had the
+// given title: the slug from the front-matter if it has one, otherwise one
+// made from the title
+func (page *Page) slugFor(title string) string {
+	if strings.TrimSpace(page.CustomSlug) != "" {
+		return Slugs.Slugify(page.CustomSlug)
+	}
+
+	return Slugs.Slugify(title)
+}
+
+// pageFileName returns the file name for a page with the given timestamp
+// prefix and slug
+func pageFileName(prefix string, slug string) string {
+	return fmt.Sprintf("%s-%s.%s", prefix, slug, FileExtension)
+}
+
+// uniqueFilePath returns the path to a page file in dir with the given prefix
+// and slug that no other file has, adding a numbered suffix to the slug if it
+// must (ie: new-title-2). The file at ownPath, if any, doesn't count, so that
+// a page keeps its own file name
+func uniqueFilePath(dir string, prefix string, slug string, ownPath string) string {
+	filePath := filepath.Join(dir, pageFileName(prefix, slug))
+
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filePath); filePath == ownPath || os.IsNotExist(err) {
+			return filePath
+		}
+
+		filePath = filepath.Join(dir, pageFileName(prefix, fmt.Sprintf("%s%s%d", slug, Slugs.Separator, i)))
+	}
+}
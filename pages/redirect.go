@@ -0,0 +1,58 @@
+package pages
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"strings"
+)
+
+// RedirectExtension is the extension redirect stubs are written with. They
+// are plain HTML, so that they work whether or not the site runs plugins
+const RedirectExtension = "html"
+
+// redirectTemplate is the page left behind at an old URL. It's the same
+// meta-refresh page that jekyll-redirect-from generates
+const redirectTemplate = `<!DOCTYPE html>
+<html lang="en-US">
+  <meta charset="utf-8">
+  <title>Redirecting&hellip;</title>
+  <link rel="canonical" href="%[1]s">
+  <meta http-equiv="refresh" content="0; url=%[1]s">
+  <meta name="robots" content="noindex">
+  <h1>Redirecting&hellip;</h1>
+  <a href="%[1]s">Click here if you are not redirected.</a>
+  <script>location="%[1]s"</script>
+</html>
+`
+
+// RedirectStub returns the HTML for a page that redirects to the given URL
+func RedirectStub(url string) string {
+	return fmt.Sprintf(redirectTemplate, html.EscapeString(url))
+}
+
+// RedirectTarget returns the URL the redirect stub HTML points at, or "" if
+// it isn't a redirect stub
+func RedirectTarget(stub string) string {
+	const marker = `<link rel="canonical" href="`
+
+	idx := strings.Index(stub, marker)
+	if idx < 0 {
+		return ""
+	}
+
+	url := stub[idx+len(marker):]
+
+	end := strings.Index(url, `"`)
+	if end < 0 {
+		return ""
+	}
+
+	return html.UnescapeString(url[:end])
+}
+
+// WriteRedirectStub writes a redirect stub to filePath that sends visitors
+// on to the given URL
+func WriteRedirectStub(filePath string, url string) error {
+	return ioutil.WriteFile(filePath, []byte(RedirectStub(url)), 0644)
+}
@@ -0,0 +1,29 @@
+package pages
+
+import (
+	"strings"
+	"time"
+)
+
+// Revision represents a single commit in a page's history
+type Revision struct {
+	Author  string
+	Email   string
+	Hash    string
+	Message string
+	When    time.Time
+}
+
+// ShortHash returns the abbreviated form of the revision's hash
+func (rev *Revision) ShortHash() string {
+	if len(rev.Hash) < 7 {
+		return rev.Hash
+	}
+
+	return rev.Hash[:7]
+}
+
+// Subject returns the first line of the revision's commit message
+func (rev *Revision) Subject() string {
+	return strings.SplitN(strings.TrimSpace(rev.Message), "\n", 2)[0]
+}
@@ -0,0 +1,132 @@
+package pages
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// DefaultSlugMaxLength is the longest a slug gets, in characters, unless
+	// configured otherwise
+	DefaultSlugMaxLength = 60
+
+	// DefaultSlugSeparator goes between the words of a slug, unless
+	// configured otherwise
+	DefaultSlugSeparator = "-"
+
+	// untitledSlug is the slug for titles that have no letters or numbers in them
+	untitledSlug = "untitled"
+)
+
+// transliterations spells out the letters that don't decompose into a plain
+// letter and an accent
+var transliterations = map[rune]string{
+	'æ': "ae",
+	'ð': "d",
+	'đ': "d",
+	'ħ': "h",
+	'ı': "i",
+	'ł': "l",
+	'ø': "o",
+	'œ': "oe",
+	'ß': "ss",
+	'þ': "th",
+}
+
+// Slugifier turns page titles into the part of the page's file name, and so
+// its URL, that comes after the timestamp
+type Slugifier struct {
+	// MaxLength caps the length of a slug. Slugs are cut between words where
+	// possible. 0 means no limit
+	MaxLength int
+
+	// Separator goes between the words of the slug
+	Separator string
+}
+
+// Slugs is the Slugifier that page file names are made with
+var Slugs = NewSlugifier(DefaultSlugSeparator, DefaultSlugMaxLength)
+
+// NewSlugifier creates and returns an instance of Slugifier
+func NewSlugifier(separator string, maxLength int) *Slugifier {
+	return &Slugifier{
+		MaxLength: maxLength,
+		Separator: separator,
+	}
+}
+
+// IsValidSeparator returns true if the separator can safely go in a file name
+// and a URL: one or more of '-', '_', and '.'
+func IsValidSeparator(separator string) bool {
+	if separator == "" {
+		return false
+	}
+
+	for _, r := range separator {
+		if !strings.ContainsRune("-_.", r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Slugify returns the slug for the title. Accented letters lose their
+// accents, letters like 'ß' are spelled out, apostrophes are dropped, and
+// every run of anything that isn't a letter or a number (punctuation,
+// whitespace, emoji) becomes a single separator. Titles with nothing usable
+// in them get the slug "untitled"
+func (s *Slugifier) Slugify(title string) string {
+	words := strings.FieldsFunc(transliterate(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if len(words) == 0 {
+		return untitledSlug
+	}
+
+	slug := words[0]
+
+	for _, word := range words[1:] {
+		next := slug + s.Separator + word
+		if s.MaxLength > 0 && utf8.RuneCountInString(next) > s.MaxLength {
+			break
+		}
+
+		slug = next
+	}
+
+	// A single word that is too long on its own is cut short
+	if s.MaxLength > 0 && utf8.RuneCountInString(slug) > s.MaxLength {
+		slug = string([]rune(slug)[:s.MaxLength])
+	}
+
+	return slug
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// transliterate lower-cases the text and reduces it to plain letters where it
+// can. Letters with no plain equivalent, like those in 'Привет' or '日本',
+// are left as they are
+func transliterate(text string) string {
+	var sb strings.Builder
+
+	for _, r := range norm.NFKD.String(strings.ToLower(text)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Accents, split off from their letters by the decomposition
+		case r == '\'' || r == '’':
+			// "don't" reads better as "dont" than "don-t"
+		case transliterations[r] != "":
+			sb.WriteString(transliterations[r])
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
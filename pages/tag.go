@@ -2,28 +2,92 @@ package pages
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"unicode"
 )
 
-// Tag represents a page tag (e.g.: linux, zombies)
+const (
+	// TagDirectory is the directory, inside the docs directory, that the tag
+	// pages are written into. It belongs to til and is rebuilt from scratch
+	TagDirectory = "tags"
+
+	// TagSeparator separates the levels of a hierarchical tag, ie: lang/go
+	TagSeparator = "/"
+
+	// emptySlug stands in for a tag level that has nothing usable in it
+	emptySlug = "_"
+)
+
+// reservedTagSlugs are the slugs that would clash with pages Jekyll or til
+// treat specially if a tag page were written with them
+var reservedTagSlugs = map[string]bool{
+	"index": true,
+}
+
+// TagPathError describes a tag whose page can't be written safely
+type TagPathError struct {
+	Name string
+	Path string
+	Msg  string
+}
+
+// Error returns the error in the form: tag 'name' (path): msg
+func (err *TagPathError) Error() string {
+	return fmt.Sprintf("tag '%s' (%s): %s", err.Name, err.Path, err.Msg)
+}
+
+// Tag represents a page tag (e.g.: linux, zombies) and the set of pages
+// tagged with it. The pages are kept in reverse-chronological order
 type Tag struct {
 	Name  string
 	Pages []*Page
+
+	members map[*Page]bool
 }
 
-// NewTag creates and returns an instance of Tag
+// NewTag creates and returns an instance of Tag. The page can be nil, for a
+// tag that is only needed for its name
 func NewTag(name string, page *Page) *Tag {
 	tag := &Tag{
-		Name:  strings.TrimSpace(name),
-		Pages: []*Page{page},
+		Name:    CleanTagName(name),
+		Pages:   []*Page{},
+		members: make(map[*Page]bool),
+	}
+
+	if page != nil {
+		tag.AddPage(page)
 	}
 
 	return tag
 }
 
-// AddPage adds a page to the list of pages
+// AddPage adds a page to the set of pages, keeping them in order. Adding a
+// page that is already in the set does nothing
 func (tag *Tag) AddPage(page *Page) {
-	tag.Pages = append(tag.Pages, page)
+	if tag.Has(page) {
+		return
+	}
+
+	if tag.members == nil {
+		tag.members = make(map[*Page]bool)
+	}
+
+	// Pages mostly arrive in order already, so this usually appends
+	idx := sort.Search(len(tag.Pages), func(i int) bool {
+		return pageBefore(page, tag.Pages[i])
+	})
+
+	tag.Pages = append(tag.Pages, nil)
+	copy(tag.Pages[idx+1:], tag.Pages[idx:])
+	tag.Pages[idx] = page
+
+	tag.members[page] = true
+}
+
+// Has returns true if the page is tagged with this tag
+func (tag *Tag) Has(page *Page) bool {
+	return tag.members[page]
 }
 
 // IsValid returns true if this is a valid tag, false if it is not
@@ -31,15 +95,166 @@ func (tag *Tag) IsValid() bool {
 	return tag.Name != ""
 }
 
+// Len returns the number of pages tagged with this tag
+func (tag *Tag) Len() int {
+	return len(tag.Pages)
+}
+
 // Link returns a link string suitable for embedding in a Markdown page
 func (tag *Tag) Link() string {
+	return tag.RelativeLink("", tag.Name)
+}
+
+// RelativeLink returns a link string, with the given text, suitable for
+// embedding in a Markdown page that lives rootPath away from the docs
+// directory (ie: "../" for a page one directory down)
+func (tag *Tag) RelativeLink(rootPath string, text string) string {
 	if tag.Name == "" {
 		return ""
 	}
 
+	if rootPath == "" {
+		rootPath = "./"
+	}
+
 	return fmt.Sprintf(
-		"[%s](%s)",
-		tag.Name,
-		fmt.Sprintf("./%s", tag.Name),
+		"[%s](%s%s)",
+		text,
+		rootPath,
+		TagPath(tag.Name),
 	)
 }
+
+// ShortName returns the last level of a hierarchical tag's name, ie: "go" for "lang/go"
+func (tag *Tag) ShortName() string {
+	return leafTagName(tag.Name)
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// CleanTagName trims the whitespace from around the tag name and from
+// around each of its levels, and drops any empty levels
+func CleanTagName(name string) string {
+	levels := []string{}
+
+	for _, level := range strings.Split(name, TagSeparator) {
+		level = strings.TrimSpace(level)
+		if level != "" {
+			levels = append(levels, level)
+		}
+	}
+
+	return strings.Join(levels, TagSeparator)
+}
+
+// ParentTagName returns the name of the tag one level up from the given
+// one, ie: "lang" for "lang/go". Top-level tags have no parent, and return ""
+func ParentTagName(name string) string {
+	idx := strings.LastIndex(name, TagSeparator)
+	if idx < 0 {
+		return ""
+	}
+
+	return name[:idx]
+}
+
+// CheckTagPaths returns an error for every tag name that can't have a page
+// of its own: names with a level that slugifies to nothing, names that use a
+// reserved slug, and names that slugify to the same path as another tag. On
+// a case-insensitive filesystem, paths that only differ by case are the same
+func CheckTagPaths(tagNames []string, caseInsensitive bool) []*TagPathError {
+	errs := []*TagPathError{}
+	claimed := map[string]string{}
+
+	for _, name := range tagNames {
+		path := TagPath(name)
+
+		if msg := checkTagLevels(name); msg != "" {
+			errs = append(errs, &TagPathError{Name: name, Path: path, Msg: msg})
+			continue
+		}
+
+		key := path
+		if caseInsensitive {
+			key = strings.ToLower(path)
+		}
+
+		if other, ok := claimed[key]; ok {
+			errs = append(errs, &TagPathError{Name: name, Path: path, Msg: fmt.Sprintf("collides with tag '%s'", other)})
+			continue
+		}
+
+		claimed[key] = name
+	}
+
+	return errs
+}
+
+// checkTagLevels returns a message describing the first level of the tag
+// name that can't be used as a file name, or "" if they all can
+func checkTagLevels(name string) string {
+	for _, level := range strings.Split(name, TagSeparator) {
+		slug := TagSlug(level)
+
+		switch {
+		case slug == "":
+			return fmt.Sprintf("'%s' has no letters or numbers in it", level)
+		case reservedTagSlugs[slug]:
+			return fmt.Sprintf("'%s' is a reserved name", level)
+		}
+	}
+
+	return ""
+}
+
+// TagPath returns the path to a tag's page, relative to the docs directory
+// and without the file extension. Tag pages live in the TagDirectory, and
+// each level of the tag becomes a directory named with its slug
+func TagPath(name string) string {
+	levels := []string{TagDirectory}
+
+	for _, level := range strings.Split(name, TagSeparator) {
+		slug := TagSlug(level)
+		if slug == "" {
+			slug = emptySlug
+		}
+
+		levels = append(levels, slug)
+	}
+
+	return strings.Join(levels, "/")
+}
+
+// TagDepth returns how many directories down from the docs directory the
+// tag's page lives
+func TagDepth(name string) int {
+	return strings.Count(TagPath(name), "/")
+}
+
+// TagSlug turns one level of a tag name into something that is safe to use
+// as a file name and in a URL: its letters and numbers, as they were typed,
+// with every run of anything else replaced by a single dash. A level with no
+// letters or numbers in it returns ""
+func TagSlug(level string) string {
+	words := strings.FieldsFunc(level, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(words, "-")
+}
+
+// pageBefore returns true if page a comes before page b in a tag's pages:
+// newest first, with pages created at the same time ordered by file path and
+// then by title so that the order is always the same
+func pageBefore(a *Page, b *Page) bool {
+	aTime, bTime := a.CreatedAt(), b.CreatedAt()
+
+	switch {
+	case !aTime.Equal(bTime):
+		return aTime.After(bTime)
+	case a.FilePath != b.FilePath:
+		return a.FilePath < b.FilePath
+	default:
+		return a.Title < b.Title
+	}
+}
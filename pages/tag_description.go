@@ -0,0 +1,132 @@
+package pages
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ericaro/frontmatter"
+	"gopkg.in/yaml.v2"
+)
+
+// TagDescription is the hand-written content that goes on a tag's page
+// along with the list of pages. It can come from a tag description file,
+// where the body of the file is the intro:
+//	---
+//	related: [rust, zig]
+//	pinned: [2020-04-20T14-52-57-getting-started]
+//	---
+//
+//	Notes on the Go programming language.
+// or from the config, where it can be written either as a mapping with the
+// same keys as the front-matter plus "intro", or as just the intro text
+type TagDescription struct {
+	Intro   string   `fm:"content" yaml:"intro"`
+	Related TagList  `yaml:"related"`
+	Pinned  []string `yaml:"pinned"`
+}
+
+// LoadTagDescription reads the tag description file at filePath. A file
+// that doesn't exist returns nil, and no error
+func LoadTagDescription(filePath string) (*TagDescription, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	desc := new(TagDescription)
+
+	err = frontmatter.Unmarshal(data, desc)
+	if err != nil {
+		return nil, newParseError(filePath, err)
+	}
+
+	desc.Intro = strings.TrimSpace(desc.Intro)
+
+	return desc, nil
+}
+
+// IsEmpty returns true if the description has nothing to add to a tag page
+func (desc *TagDescription) IsEmpty() bool {
+	return desc == nil || (desc.Intro == "" && len(desc.Related) == 0 && len(desc.Pinned) == 0)
+}
+
+// Merge returns a description made of this one, with any fields it leaves
+// blank filled in from other. Either can be nil
+func (desc *TagDescription) Merge(other *TagDescription) *TagDescription {
+	if desc == nil {
+		return other
+	}
+
+	if other == nil {
+		return desc
+	}
+
+	merged := *desc
+
+	if merged.Intro == "" {
+		merged.Intro = other.Intro
+	}
+
+	if len(merged.Related) == 0 {
+		merged.Related = other.Related
+	}
+
+	if len(merged.Pinned) == 0 {
+		merged.Pinned = other.Pinned
+	}
+
+	return &merged
+}
+
+// UnmarshalYAML accepts either a mapping of the description's fields, or a
+// plain string that becomes the intro
+func (desc *TagDescription) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var intro string
+	if err := unmarshal(&intro); err == nil {
+		desc.Intro = strings.TrimSpace(intro)
+		return nil
+	}
+
+	type rawTagDescription TagDescription
+	raw := rawTagDescription{}
+
+	err := unmarshal(&raw)
+	if err != nil {
+		return err
+	}
+
+	*desc = TagDescription(raw)
+	desc.Intro = strings.TrimSpace(desc.Intro)
+
+	return nil
+}
+
+// TagDescriptionsFromConfig turns the tagDescriptions config map into tag
+// descriptions, keyed by their normalised tag names
+func TagDescriptionsFromConfig(descMap map[string]interface{}) (map[string]*TagDescription, error) {
+	descs := make(map[string]*TagDescription, len(descMap))
+
+	for name, value := range descMap {
+		// Round-trip the value through YAML so that it gets the same parsing
+		// as a description file's front-matter
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		desc := new(TagDescription)
+
+		err = yaml.Unmarshal(data, desc)
+		if err != nil {
+			return nil, err
+		}
+
+		descs[Normaliser.Normalise(name)] = desc
+	}
+
+	return descs, nil
+}
@@ -0,0 +1,178 @@
+package pages
+
+import (
+	"sort"
+	"strings"
+)
+
+// TagPair is two tag names that look like they might be the same tag
+type TagPair struct {
+	A        string
+	B        string
+	Distance int
+}
+
+// Untagged returns the content pages in the set that have no tags
+func Untagged(pageSet []*Page) []*Page {
+	untagged := []*Page{}
+
+	for _, page := range pageSet {
+		if page.IsContentPage() && len(page.Tags()) == 0 {
+			untagged = append(untagged, page)
+		}
+	}
+
+	return untagged
+}
+
+// SingletonTags returns the names of the tags in the map that only one page
+// uses, in alphabetical order. Parent tags are left out, as they collect the
+// pages of their children
+func SingletonTags(tm *TagMap) []string {
+	singletons := []string{}
+
+	for _, tagName := range tm.SortedTagNames() {
+		if tm.Count(tagName) == 1 && len(tm.Children(tagName)) == 0 {
+			singletons = append(singletons, tagName)
+		}
+	}
+
+	return singletons
+}
+
+// CaseOnlyTags returns the groups of tag names, as they are written in the
+// pages' front-matter, that only differ from each other by case. Each group
+// and the list of groups are in alphabetical order
+func CaseOnlyTags(pageSet []*Page) [][]string {
+	spellings := map[string]map[string]bool{}
+
+	for _, page := range pageSet {
+		for _, name := range page.TagNames {
+			name = CleanTagName(name)
+			if name == "" {
+				continue
+			}
+
+			folded := strings.ToLower(name)
+			if spellings[folded] == nil {
+				spellings[folded] = map[string]bool{}
+			}
+
+			spellings[folded][name] = true
+		}
+	}
+
+	groups := [][]string{}
+
+	for _, names := range spellings {
+		if len(names) < 2 {
+			continue
+		}
+
+		group := []string{}
+		for name := range names {
+			group = append(group, name)
+		}
+		sort.Strings(group)
+
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+
+	return groups
+}
+
+// NearDuplicateTags returns the pairs of tag names that are only a letter or
+// two apart (ie: "kubernetes" and "kubernets"), ignoring case. Short names
+// have to be closer together than long ones to count
+func NearDuplicateTags(tagNames []string) []*TagPair {
+	pairs := []*TagPair{}
+
+	names := make([]string, len(tagNames))
+	copy(names, tagNames)
+	sort.Strings(names)
+
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			foldedA, foldedB := strings.ToLower(a), strings.ToLower(b)
+
+			// Tags that only differ by case are reported by CaseOnlyTags
+			if foldedA == foldedB {
+				continue
+			}
+
+			distance := editDistance(foldedA, foldedB)
+			if distance <= maxTagDistance(foldedA, foldedB) {
+				pairs = append(pairs, &TagPair{A: a, B: b, Distance: distance})
+			}
+		}
+	}
+
+	return pairs
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// editDistance returns the Levenshtein distance between the two strings:
+// the number of single-letter insertions, deletions, and substitutions it
+// takes to turn one into the other
+func editDistance(a string, b string) int {
+	aRunes, bRunes := []rune(a), []rune(b)
+
+	prev := make([]int, len(bRunes)+1)
+	curr := make([]int, len(bRunes)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(aRunes); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(bRunes); j++ {
+			cost := 1
+			if aRunes[i-1] == bRunes[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(bRunes)]
+}
+
+// maxTagDistance returns how far apart two tag names can be and still count
+// as near-duplicates. A letter or two is enough to turn a short name into a
+// different word entirely (ie: "go" and "js", "lang/rust" and "lang/ruby"),
+// so it depends on the length of the last level of the shorter name
+func maxTagDistance(a string, b string) int {
+	shortest := minInt(len([]rune(leafTagName(a))), len([]rune(leafTagName(b))))
+
+	switch {
+	case shortest < 4:
+		return 0
+	case shortest < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// leafTagName returns the last level of a hierarchical tag's name
+func leafTagName(name string) string {
+	return name[strings.LastIndex(name, TagSeparator)+1:]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
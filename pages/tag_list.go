@@ -0,0 +1,52 @@
+package pages
+
+import (
+	"strings"
+)
+
+// TagList is the list of tag names in a page's front-matter. In YAML it can
+// be written either as a sequence or as a comma-separated string:
+//	tags: [go, testing]
+//	tags: go, testing
+type TagList []string
+
+// ParseTagList splits a comma-separated string of tag names into a TagList
+func ParseTagList(str string) TagList {
+	return cleanTagList(strings.Split(str, ","))
+}
+
+// UnmarshalYAML accepts either a YAML sequence or a comma-separated string
+func (tl *TagList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	names := []string{}
+
+	err := unmarshal(&names)
+	if err == nil {
+		*tl = cleanTagList(names)
+		return nil
+	}
+
+	str := ""
+
+	err = unmarshal(&str)
+	if err != nil {
+		return err
+	}
+
+	*tl = ParseTagList(str)
+
+	return nil
+}
+
+// cleanTagList trims the names and drops any that are blank
+func cleanTagList(names []string) TagList {
+	tl := TagList{}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			tl = append(tl, name)
+		}
+	}
+
+	return tl
+}
@@ -4,15 +4,20 @@ import (
 	"sort"
 )
 
-// TagMap is a map of tag name to Tag instance
+// TagMap is an inverted index of tag name to the Tag holding the pages with
+// that tag. It also remembers which tags each page ended up with, so that it
+// can answer which tags are used together
 type TagMap struct {
-	Tags map[string][]*Tag
+	Tags map[string]*Tag
+
+	pageTags map[*Page][]string
 }
 
 // NewTagMap creates and returns an instance of TagMap
 func NewTagMap(pageSet []*Page) *TagMap {
 	tm := &TagMap{
-		Tags: make(map[string][]*Tag),
+		Tags:     make(map[string]*Tag),
+		pageTags: make(map[*Page][]string),
 	}
 
 	tm.BuildFromPages(pageSet)
@@ -20,49 +25,124 @@ func NewTagMap(pageSet []*Page) *TagMap {
 	return tm
 }
 
-// Add adds a Tag instance to the map
+// Add adds a Tag instance to the map, merging its pages into the pages
+// already in the map for that tag name
 func (tm *TagMap) Add(tag *Tag) {
 	if !tag.IsValid() {
 		return
 	}
 
-	tm.Tags[tag.Name] = append(tm.Tags[tag.Name], tag)
+	existing, ok := tm.Tags[tag.Name]
+	if !ok {
+		existing = NewTag(tag.Name, nil)
+		tm.Tags[tag.Name] = existing
+	}
+
+	for _, page := range tag.Pages {
+		if existing.Has(page) {
+			continue
+		}
+
+		existing.AddPage(page)
+		tm.pageTags[page] = append(tm.pageTags[page], tag.Name)
+	}
 }
 
-// BuildFromPages populates the tag map from a slice of Page instances
+// BuildFromPages populates the tag map from a slice of Page instances.
+// Pages that are not listed (drafts, scheduled, unlisted, archived) are skipped
 func (tm *TagMap) BuildFromPages(pages []*Page) {
 	for _, page := range pages {
+		if !page.IsListed() {
+			continue
+		}
+
 		for _, tag := range page.Tags() {
-			tm.Add(tag)
+			// Parent tags collect the pages of all their children
+			for name := tag.Name; name != ""; name = ParentTagName(name) {
+				tm.Add(NewTag(name, page))
+			}
+		}
+	}
+}
+
+// Children returns the names of the tags one level below the given tag, in
+// alphabetical order. The children of "" are the top-level tags
+func (tm *TagMap) Children(name string) []string {
+	children := []string{}
+
+	for _, tagName := range tm.SortedTagNames() {
+		if ParentTagName(tagName) == name {
+			children = append(children, tagName)
+		}
+	}
+
+	return children
+}
+
+// CoOccurrences returns the number of pages each other tag shares with the
+// given tag. The tag's own ancestors and descendants are left out, as they
+// share its pages by definition
+func (tm *TagMap) CoOccurrences(name string) map[string]int {
+	counts := make(map[string]int)
+
+	for _, page := range tm.PagesFor(name) {
+		for _, other := range tm.pageTags[page] {
+			if other == name || isTagAncestor(other, name) || isTagAncestor(name, other) {
+				continue
+			}
+
+			counts[other]++
 		}
 	}
+
+	return counts
+}
+
+// Count returns the number of pages with the given tag
+func (tm *TagMap) Count(name string) int {
+	return len(tm.PagesFor(name))
 }
 
-// Get returns the tags for a given tag name
-func (tm *TagMap) Get(name string) []*Tag {
+// Get returns the tag for a given tag name, or nil if no page has that tag
+func (tm *TagMap) Get(name string) *Tag {
 	return tm.Tags[name]
 }
 
+// Has returns true if the page has the given tag
+func (tm *TagMap) Has(name string, page *Page) bool {
+	tag, ok := tm.Tags[name]
+	if !ok {
+		return false
+	}
+
+	return tag.Has(page)
+}
+
+// IsHierarchical returns true if any of the tags in the map have children
+func (tm *TagMap) IsHierarchical() bool {
+	for tagName := range tm.Tags {
+		if ParentTagName(tagName) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Len returns the number of tags in the map
 func (tm *TagMap) Len() int {
 	return len(tm.Tags)
 }
 
-// PagesFor returns a flattened slice of pages for a given tag name, sorted
-// in reverse-chronological order
+// PagesFor returns the pages for a given tag name, in reverse-chronological
+// order. The slice belongs to the map and must not be modified
 func (tm *TagMap) PagesFor(tagName string) []*Page {
-	pages := []*Page{}
-	tags := tm.Get(tagName)
-
-	for _, tag := range tags {
-		pages = append(pages, tag.Pages...)
-
-		sort.Slice(pages, func(i, j int) bool {
-			return pages[i].CreatedAt().After(pages[j].CreatedAt())
-		})
+	tag, ok := tm.Tags[tagName]
+	if !ok {
+		return []*Page{}
 	}
 
-	return pages
+	return tag.Pages
 }
 
 // SortedTagNames returns the tag names in alphabetical order
@@ -79,3 +159,17 @@ func (tm *TagMap) SortedTagNames() []string {
 
 	return tagArr
 }
+
+/* -------------------- Helper functions -------------------- */
+
+// isTagAncestor returns true if the tag named ancestor is above the tag
+// named name in the hierarchy, ie: "lang" is an ancestor of "lang/go"
+func isTagAncestor(ancestor string, name string) bool {
+	for parent := ParentTagName(name); parent != ""; parent = ParentTagName(parent) {
+		if parent == ancestor {
+			return true
+		}
+	}
+
+	return false
+}
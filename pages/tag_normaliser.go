@@ -0,0 +1,56 @@
+package pages
+
+import (
+	"strings"
+)
+
+// TagNormaliser turns the tag names written in page front-matter into the
+// canonical names that pages are grouped by, so that "Go", "go", and "golang"
+// can all end up on the same tag page
+type TagNormaliser struct {
+	// CaseFold lower-cases every tag name
+	CaseFold bool
+
+	// Aliases maps alternative tag names onto their canonical name, ie: golang -> go
+	Aliases map[string]string
+}
+
+// Normaliser is the TagNormaliser that Page.Tags() applies. By default it
+// only cleans up whitespace
+var Normaliser = &TagNormaliser{}
+
+// NewTagNormaliser creates and returns an instance of TagNormaliser
+func NewTagNormaliser(caseFold bool, aliases map[string]string) *TagNormaliser {
+	tn := &TagNormaliser{
+		CaseFold: caseFold,
+		Aliases:  make(map[string]string, len(aliases)),
+	}
+
+	// Store the aliases in their normalised form so that lookups match
+	// however the alias was written in the config
+	for alias, canonical := range aliases {
+		tn.Aliases[tn.fold(CleanTagName(alias))] = canonical
+	}
+
+	return tn
+}
+
+// Normalise returns the canonical form of the tag name
+func (tn *TagNormaliser) Normalise(name string) string {
+	name = tn.fold(CleanTagName(name))
+
+	if canonical, ok := tn.Aliases[name]; ok {
+		name = tn.fold(CleanTagName(canonical))
+	}
+
+	return name
+}
+
+// fold lower-cases the name, if case-folding is turned on
+func (tn *TagNormaliser) fold(name string) string {
+	if tn.CaseFold {
+		return strings.ToLower(name)
+	}
+
+	return name
+}
@@ -0,0 +1,96 @@
+package pages
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// MaxTagWeight is the weight given to the most-used tags. The least-used
+// tags have a weight of 1
+const MaxTagWeight = 5
+
+// TagStat summarises how a tag is used
+type TagStat struct {
+	Name     string
+	Count    int
+	LastUsed time.Time
+
+	// Weight ranks the tag's count against the other tags' counts, from 1 to
+	// MaxTagWeight, on a log scale so that a few very popular tags don't
+	// flatten everything else to the same weight
+	Weight int
+}
+
+// Stats returns the usage statistics for every tag in the map, in
+// alphabetical order
+func (tm *TagMap) Stats() []*TagStat {
+	stats := []*TagStat{}
+	minCount, maxCount := math.MaxInt32, 0
+
+	for _, tagName := range tm.SortedTagNames() {
+		tag := tm.Get(tagName)
+
+		stat := &TagStat{
+			Name:  tagName,
+			Count: tag.Len(),
+		}
+
+		// The tag's pages are newest first
+		if stat.Count > 0 {
+			stat.LastUsed = tag.Pages[0].CreatedAt()
+		}
+
+		if stat.Count < minCount {
+			minCount = stat.Count
+		}
+
+		if stat.Count > maxCount {
+			maxCount = stat.Count
+		}
+
+		stats = append(stats, stat)
+	}
+
+	for _, stat := range stats {
+		stat.Weight = tagWeight(stat.Count, minCount, maxCount)
+	}
+
+	return stats
+}
+
+// TopTagStats returns the limit most-used tags, in alphabetical order. Tags
+// with the same count are picked alphabetically
+func TopTagStats(stats []*TagStat, limit int) []*TagStat {
+	if limit <= 0 || limit >= len(stats) {
+		return stats
+	}
+
+	top := make([]*TagStat, len(stats))
+	copy(top, stats)
+
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Count > top[j].Count
+	})
+
+	top = top[:limit]
+
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Name < top[j].Name
+	})
+
+	return top
+}
+
+// tagWeight maps count onto 1..MaxTagWeight, given the smallest and largest
+// counts of all the tags
+func tagWeight(count int, minCount int, maxCount int) int {
+	if maxCount <= minCount {
+		return 1
+	}
+
+	spread := math.Log(float64(maxCount)) - math.Log(float64(minCount))
+	position := (math.Log(float64(count)) - math.Log(float64(minCount))) / spread
+
+	return 1 + int(math.Round(position*float64(MaxTagWeight-1)))
+}
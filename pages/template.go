@@ -0,0 +1,301 @@
+package pages
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// TemplateDirectory is where page templates live, in the root of the target
+	TemplateDirectory = "templates"
+
+	// DefaultTemplateName is the template new pages get when no other
+	// template is chosen for them, if it exists
+	DefaultTemplateName = "default"
+)
+
+// standInRegex matches the stand-ins that a template's placeholders are
+// rendered as in its front-matter
+var standInRegex = regexp.MustCompile(`TILVALUE(\d+)TIL`)
+
+// Template is the starting point for a new page: front-matter and content
+// with placeholders in them, ie: {{.Title}}, {{.Date}}, {{prompt "Command?"}}
+type Template struct {
+	Name string
+	Text string
+}
+
+// TemplateData holds the values a template's placeholders can refer to
+type TemplateData struct {
+	Date   string
+	Tags   TagList
+	Target string
+	Title  string
+}
+
+// LoadTemplate reads the template with the given name from the templates
+// directory in root. If there is no such template it returns nil and no error
+func LoadTemplate(root string, name string) (*Template, error) {
+	data, err := ioutil.ReadFile(templatePath(root, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &Template{Name: name, Text: string(data)}, nil
+}
+
+// TemplateNames returns the names of the templates in the templates
+// directory in root, in alphabetical order
+func TemplateNames(root string) []string {
+	filePaths, _ := filepath.Glob(templatePath(root, "*"))
+	names := []string{}
+
+	for _, filePath := range filePaths {
+		names = append(names, strings.TrimSuffix(filepath.Base(filePath), "."+FileExtension))
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// NewPageFromTemplate creates a page like NewPage does, with the given tags,
+// and with the front-matter and content the template renders to. The page's
+// title and date are always the ones til gives it. Template placeholders
+// that prompt for a value are answered by ask. A nil template gives the
+// same page as NewPage
+func NewPageFromTemplate(title string, tagNames TagList, targetDir string, tmpl *Template, ask func(question string) string) (*Page, error) {
+	date := time.Now()
+
+	page := &Page{}
+
+	if tmpl != nil {
+		err := tmpl.renderPage(page, &TemplateData{
+			Date:   date.Format("Jan 02, 2006"),
+			Tags:   tagNames,
+			Target: filepath.Dir(targetDir),
+			Title:  title,
+		}, ask)
+		if err != nil {
+			return nil, fmt.Errorf("template '%s': %s", tmpl.Name, err)
+		}
+	}
+
+	if page.Layout == "" {
+		page.Layout = defaultLayout
+	}
+
+	page.Date = date.Format(time.RFC3339)
+	page.FilePath = uniqueFilePath(targetDir, date.Format(ghFriendlyDateFormat), page.slugFor(title), "")
+	page.TagNames = mergeTagLists(tagNames, page.TagNames)
+	page.Title = title
+
+	page.Save()
+
+	return page, nil
+}
+
+// Prompts returns true if creating a page from the template asks for any values
+func (tmpl *Template) Prompts() bool {
+	prompts := false
+
+	ask := func(string) string {
+		prompts = true
+		return ""
+	}
+
+	_ = tmpl.renderPage(&Page{}, &TemplateData{}, ask)
+
+	return prompts
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// renderPage fills in the page's front-matter and content from the template
+func (tmpl *Template) renderPage(page *Page, data *TemplateData, ask func(question string) string) error {
+	fmLines, body, err := splitFrontMatter([]byte(tmpl.Text))
+	if err == errNoFrontMatter {
+		fmLines, body = []string{}, tmpl.Text
+	} else if err != nil {
+		return err
+	}
+
+	fields, err := tmpl.renderFrontMatter(strings.Join(fmLines, "\n"), data, ask)
+	if err != nil {
+		return err
+	}
+
+	fmData, err := yaml.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	err = yaml.Unmarshal(fmData, page)
+	if err != nil {
+		return err
+	}
+
+	page.Content, err = tmpl.render(body, data, ask)
+
+	return err
+}
+
+// renderFrontMatter renders the template's front-matter and parses it. The
+// placeholders are rendered as stand-ins that are safe anywhere in YAML, and
+// the values they stand for are only put in once the YAML has been parsed, so
+// values like "fix: the build" don't need quoting in the template
+func (tmpl *Template) renderFrontMatter(text string, data *TemplateData, ask func(question string) string) (yaml.MapSlice, error) {
+	values := []string{}
+
+	standIn := func(value string) string {
+		values = append(values, value)
+		return fmt.Sprintf("TILVALUE%dTIL", len(values)-1)
+	}
+
+	standInTags := TagList{}
+	for _, name := range data.Tags {
+		standInTags = append(standInTags, standIn(name))
+	}
+
+	standInData := &TemplateData{
+		Date:   standIn(data.Date),
+		Tags:   standInTags,
+		Target: standIn(data.Target),
+		Title:  standIn(data.Title),
+	}
+
+	standInAsk := func(question string) string {
+		if ask == nil {
+			return standIn("")
+		}
+
+		return standIn(ask(question))
+	}
+
+	rendered, err := tmpl.render(text, standInData, standInAsk)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := yaml.MapSlice{}
+
+	err = yaml.Unmarshal([]byte(rendered), &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return fillStandIns(fields, values).(yaml.MapSlice), nil
+}
+
+// fillStandIns replaces the stand-ins in the parsed front-matter with the
+// values they stand for. A value that is a stand-in on its own keeps the type
+// it would have had if it had been typed in, ie: true, 42, or a string
+func fillStandIns(value interface{}, values []string) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for i := range v {
+			v[i].Key = fillStandIns(v[i].Key, values)
+			v[i].Value = fillStandIns(v[i].Value, values)
+		}
+
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = fillStandIns(v[i], values)
+		}
+
+		return v
+	case string:
+		if match := standInRegex.FindStringSubmatch(v); match != nil && match[0] == v {
+			idx, _ := strconv.Atoi(match[1])
+			return scalarValue(values[idx])
+		}
+
+		return standInRegex.ReplaceAllStringFunc(v, func(standIn string) string {
+			idx, _ := strconv.Atoi(standInRegex.FindStringSubmatch(standIn)[1])
+			return values[idx]
+		})
+	}
+
+	return value
+}
+
+// scalarValue returns the value YAML would read the text as, if that is a
+// plain value like a number or a boolean. Anything else stays a string
+func scalarValue(text string) interface{} {
+	var value interface{}
+
+	err := yaml.Unmarshal([]byte(text), &value)
+	if err != nil {
+		return text
+	}
+
+	switch value.(type) {
+	case bool, int, float64:
+		return value
+	}
+
+	return text
+}
+
+// render executes the template text. Placeholders that prompt for a value
+// are answered by ask
+func (tmpl *Template) render(text string, data *TemplateData, ask func(question string) string) (string, error) {
+	if ask == nil {
+		ask = func(string) string { return "" }
+	}
+
+	funcs := template.FuncMap{
+		"prompt": ask,
+	}
+
+	parsed, err := template.New(tmpl.Name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+
+	err = parsed.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// mergeTagLists returns the tag names in both lists, without repeats, in the
+// order they first appear
+func mergeTagLists(a TagList, b TagList) TagList {
+	merged := TagList{}
+	seen := map[string]bool{}
+
+	for _, name := range cleanTagList(append(append(TagList{}, a...), b...)) {
+		if !seen[name] {
+			merged = append(merged, name)
+			seen[name] = true
+		}
+	}
+
+	return merged
+}
+
+// templatePath returns the path to the template file with the given name
+func templatePath(root string, name string) string {
+	return filepath.Join(root, TemplateDirectory, name+"."+FileExtension)
+}
@@ -0,0 +1,170 @@
+package pages
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// TitleCaseAsTyped leaves titles exactly as they were typed
+	TitleCaseAsTyped = "as-typed"
+
+	// TitleCaseSentence capitalises the first word of a title only
+	TitleCaseSentence = "sentence"
+
+	// TitleCaseTitle capitalises every word of a title, other than small
+	// words like 'a' and 'of' in the middle of it
+	TitleCaseTitle = "title"
+)
+
+// smallWords stay lower-case in the middle of a title-cased title
+var smallWords = map[string]bool{
+	"a":    true,
+	"an":   true,
+	"and":  true,
+	"as":   true,
+	"at":   true,
+	"but":  true,
+	"by":   true,
+	"for":  true,
+	"from": true,
+	"in":   true,
+	"into": true,
+	"nor":  true,
+	"of":   true,
+	"on":   true,
+	"or":   true,
+	"per":  true,
+	"the":  true,
+	"to":   true,
+	"via":  true,
+	"vs":   true,
+	"with": true,
+}
+
+// TitleCaser cases the titles of new and renamed pages
+type TitleCaser struct {
+	// Mode is one of TitleCaseAsTyped, TitleCaseSentence, or TitleCaseTitle
+	Mode string
+
+	// Words maps the lower-case form of each word that must always be written
+	// a certain way onto that way, ie: grpc -> gRPC
+	Words map[string]string
+}
+
+// Titles is the TitleCaser that page titles are cased with. By default it
+// title-cases them
+var Titles = NewTitleCaser(TitleCaseTitle, nil)
+
+// NewTitleCaser creates and returns an instance of TitleCaser
+func NewTitleCaser(mode string, words []string) *TitleCaser {
+	tc := &TitleCaser{
+		Mode:  mode,
+		Words: make(map[string]string, len(words)),
+	}
+
+	for _, word := range words {
+		tc.Words[strings.ToLower(word)] = word
+	}
+
+	return tc
+}
+
+// IsValidTitleCase returns true if the mode is one that TitleCaser knows
+func IsValidTitleCase(mode string) bool {
+	switch mode {
+	case TitleCaseAsTyped, TitleCaseSentence, TitleCaseTitle:
+		return true
+	}
+
+	return false
+}
+
+// Case returns the title cased according to the mode. Whatever the mode,
+// the words in the dictionary are written the way the dictionary has them,
+// and words that look like acronyms, brand names, or code (ie: TLS, gRPC,
+// k8s, main.go) are left as they were typed
+func (tc *TitleCaser) Case(title string) string {
+	words := strings.Fields(title)
+
+	for i, word := range words {
+		first := i == 0
+		last := i == len(words)-1
+
+		// A word after a colon starts a new phrase, ie: "Go: The Good Parts"
+		if i > 0 && strings.HasSuffix(words[i-1], ":") {
+			first = true
+		}
+
+		words[i] = tc.caseWord(word, first, last)
+	}
+
+	return strings.Join(words, " ")
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// caseWord cases one word of a title, given whether it starts or ends a phrase
+func (tc *TitleCaser) caseWord(word string, first bool, last bool) string {
+	// Punctuation around the word, ie: quotes and brackets, is kept as it is
+	start := strings.IndexFunc(word, isWordRune)
+	if start < 0 {
+		return word
+	}
+
+	end := strings.LastIndexFunc(word, isWordRune)
+	_, size := utf8.DecodeRuneInString(word[end:])
+	prefix, core, suffix := word[:start], word[start:end+size], word[end+size:]
+
+	if preserved, ok := tc.Words[strings.ToLower(core)]; ok {
+		return prefix + preserved + suffix
+	}
+
+	if tc.Mode == TitleCaseAsTyped || isLiteralWord(core) {
+		return word
+	}
+
+	switch {
+	case first:
+		core = capitalise(core)
+	case tc.Mode == TitleCaseSentence:
+		core = strings.ToLower(core)
+	case smallWords[strings.ToLower(core)] && !last:
+		core = strings.ToLower(core)
+	default:
+		core = capitalise(core)
+	}
+
+	return prefix + core + suffix
+}
+
+// capitalise upper-cases the first letter of the word, leaving the rest alone
+func capitalise(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+
+	return string(unicode.ToTitle(r)) + word[size:]
+}
+
+// isLiteralWord returns true if the word should be left exactly as it was
+// typed: it has capitals after its first letter (TLS, gRPC, iPhone), numbers
+// (k8s, http2), or characters that suggest code or a file name (main.go,
+// snake_case, /usr/bin)
+func isLiteralWord(word string) bool {
+	for i, r := range word {
+		switch {
+		case unicode.IsDigit(r), strings.ContainsRune("._/\\@#$%&=+", r):
+			return true
+		case i > 0 && unicode.IsUpper(r):
+			return true
+		}
+	}
+
+	return false
+}
+
+// isWordRune returns true for the characters that make up a word, as opposed
+// to the punctuation around it
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
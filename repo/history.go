@@ -0,0 +1,172 @@
+package repo
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// FileHistory walks the history reachable from HEAD and returns the commits
+// that changed each file under the given directory prefix, keyed by the
+// file's path relative to the repository root. Commits are newest first.
+// Renamed files keep the history they had under their old names. A commit
+// renames a file when it deletes one file and adds another with the same
+// content, or that isRename (which may be nil) says is the same file
+func FileHistory(r *git.Repository, prefix string, isRename func(from string, to string) bool) (map[string][]*object.Commit, error) {
+	history := make(map[string][]*object.Commit)
+
+	// renamedTo maps the old names of renamed files onto their newer names
+	renamedTo := make(map[string]string)
+
+	currentName := func(path string) string {
+		for {
+			newer, ok := renamedTo[path]
+			if !ok {
+				return path
+			}
+
+			path = newer
+		}
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		// An empty repository has no history to speak of
+		return history, nil
+	}
+
+	cIter, err := r.Log(&git.LogOptions{From: head.Hash(), Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cIter.ForEach(func(c *object.Commit) error {
+		changes, err := parentChanges(c)
+		if err != nil {
+			return err
+		}
+
+		// This commit, and older ones, know renamed files by their old names
+		for from, to := range renames(changes, isRename) {
+			// A file renamed back to an older name is known by that name
+			if newest := currentName(to); newest != from {
+				renamedTo[from] = newest
+			}
+		}
+
+		for _, change := range changes {
+			path := changePath(change)
+			if !strings.HasPrefix(path, prefix) {
+				continue
+			}
+
+			name := currentName(path)
+
+			// A rename shows up as two changes to the same file
+			commits := history[name]
+			if len(commits) > 0 && commits[len(commits)-1] == c {
+				continue
+			}
+
+			history[name] = append(commits, c)
+		}
+
+		return nil
+	})
+	if err != nil && err != storer.ErrStop {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// changedPaths returns the paths of the files a commit changed relative to
+// its first parent (or, for the root commit, every file it contains)
+func changedPaths(c *object.Commit) ([]string, error) {
+	changes, err := parentChanges(c)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, change := range changes {
+		paths = append(paths, changePath(change))
+	}
+
+	return paths, nil
+}
+
+// changePath returns the path of the file the change is to: its new path,
+// or for a deletion the path it had
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+
+	return change.From.Name
+}
+
+// parentChanges returns the changes a commit made to its first parent's tree
+// (or, for the root commit, to an empty tree)
+func parentChanges(c *object.Commit) (object.Changes, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var parentTree *object.Tree
+
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return object.DiffTree(parentTree, tree)
+}
+
+// renames pairs up the files the changes deleted with the files they added
+// that are the same file under a new name, and returns the old names mapped
+// onto the new ones
+func renames(changes object.Changes, isRename func(from string, to string) bool) map[string]string {
+	deleted := []*object.Change{}
+	added := []*object.Change{}
+
+	for _, change := range changes {
+		switch {
+		case change.To.Name == "":
+			deleted = append(deleted, change)
+		case change.From.Name == "":
+			added = append(added, change)
+		}
+	}
+
+	renamed := make(map[string]string)
+	taken := make(map[string]bool)
+
+	for _, del := range deleted {
+		for _, add := range added {
+			if taken[add.To.Name] {
+				continue
+			}
+
+			sameContent := del.From.TreeEntry.Hash == add.To.TreeEntry.Hash
+			if sameContent || (isRename != nil && isRename(del.From.Name, add.To.Name)) {
+				renamed[del.From.Name] = add.To.Name
+				taken[add.To.Name] = true
+
+				break
+			}
+		}
+	}
+
+	return renamed
+}
@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"bytes"
+	"strings"
+
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Hunk is one hunk of a commit's diff, in unified diff format
+type Hunk struct {
+	// Path is the path of the file the hunk changes. For deleted files it's
+	// the path the file had
+	Path string
+
+	// Text is the hunk, starting with its "@@ -1,2 +1,3 @@" line
+	Text string
+}
+
+// Header returns the hunk's "@@ ... @@" line
+func (hunk *Hunk) Header() string {
+	return strings.SplitN(hunk.Text, "\n", 2)[0]
+}
+
+// CommitPatch returns the changes the commit made to its first parent (or,
+// for the root commit, everything it added)
+func CommitPatch(c *object.Commit) (*object.Patch, error) {
+	changes, err := parentChanges(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes.Patch()
+}
+
+// Hunks splits the patch into its hunks, in the order the patch has them.
+// Binary files have no hunks
+func Hunks(patch *object.Patch) ([]*Hunk, error) {
+	hunks := []*Hunk{}
+
+	for _, filePatch := range patch.FilePatches() {
+		if filePatch.IsBinary() {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+
+		err := fdiff.NewUnifiedEncoder(buf, fdiff.DefaultContextLines).Encode(&singleFilePatch{filePatch})
+		if err != nil {
+			return nil, err
+		}
+
+		path := filePatchPath(filePatch)
+
+		var hunk *Hunk
+
+		// Everything before the first "@@" line is the file's header
+		for _, line := range strings.SplitAfter(buf.String(), "\n") {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				hunk = &Hunk{Path: path, Text: line}
+				hunks = append(hunks, hunk)
+			case hunk != nil:
+				hunk.Text += line
+			}
+		}
+	}
+
+	return hunks, nil
+}
+
+/* -------------------- Helper functions -------------------- */
+
+// singleFilePatch is a patch of just the one file, so that each file's
+// changes can be encoded on their own
+type singleFilePatch struct {
+	filePatch fdiff.FilePatch
+}
+
+func (p *singleFilePatch) FilePatches() []fdiff.FilePatch {
+	return []fdiff.FilePatch{p.filePatch}
+}
+
+func (p *singleFilePatch) Message() string {
+	return ""
+}
+
+// filePatchPath returns the path of the file the file patch changes
+func filePatchPath(filePatch fdiff.FilePatch) string {
+	from, to := filePatch.Files()
+	if to != nil {
+		return to.Path()
+	}
+
+	return from.Path()
+}
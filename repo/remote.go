@@ -0,0 +1,178 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+const defaultRemoteName = "origin"
+
+var (
+	// ErrDetachedHead is returned when HEAD does not point at a branch
+	ErrDetachedHead = errors.New("HEAD is not on a branch")
+
+	// ErrNoTrackingRef is returned when the current branch has no remote-tracking branch
+	ErrNoTrackingRef = errors.New("current branch has no remote-tracking branch")
+)
+
+// Divergence describes how a local branch and its remote-tracking branch
+// have drifted apart. Both slices are in reverse-chronological order
+type Divergence struct {
+	// Ahead holds the local commits that have not been pushed
+	Ahead []*object.Commit
+
+	// Behind holds the remote commits that have not been pulled
+	Behind []*object.Commit
+}
+
+// RemoteName returns the name of the remote the current branch tracks,
+// defaulting to "origin"
+func RemoteName(r *git.Repository) string {
+	head, err := r.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return defaultRemoteName
+	}
+
+	branch, err := r.Branch(head.Name().Short())
+	if err != nil || branch.Remote == "" {
+		return defaultRemoteName
+	}
+
+	return branch.Remote
+}
+
+// TrackingRef returns the remote-tracking reference for the branch HEAD
+// points at. If the branch has no upstream configured it falls back to the
+// branch of the same name on the default remote
+func TrackingRef(r *git.Repository) (*plumbing.Reference, error) {
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	if !head.Name().IsBranch() {
+		return nil, ErrDetachedHead
+	}
+
+	remoteName := RemoteName(r)
+	branchName := head.Name().Short()
+
+	branch, err := r.Branch(branchName)
+	if err == nil && branch.Merge != "" {
+		branchName = branch.Merge.Short()
+	}
+
+	ref, err := r.Reference(plumbing.NewRemoteReferenceName(remoteName, branchName), true)
+	if err != nil {
+		return nil, ErrNoTrackingRef
+	}
+
+	return ref, nil
+}
+
+// Fetch updates the remote-tracking branches from the current branch's remote.
+// Being already up-to-date is not considered an error
+func Fetch(r *git.Repository) error {
+	err := r.Fetch(&git.FetchOptions{RemoteName: RemoteName(r)})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+
+	return err
+}
+
+// Diverge compares HEAD with its remote-tracking branch
+func Diverge(r *git.Repository) (*Divergence, error) {
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	tracking, err := TrackingRef(r)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := reachable(r, head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := reachable(r, tracking.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	div := &Divergence{
+		Ahead:  missingFrom(local, remote),
+		Behind: missingFrom(remote, local),
+	}
+
+	return div, nil
+}
+
+// IsPushed returns true if the given commit is reachable from the current
+// branch's remote-tracking branch
+func IsPushed(r *git.Repository, hash plumbing.Hash) (bool, error) {
+	tracking, err := TrackingRef(r)
+	if err == ErrNoTrackingRef {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	remote, err := reachable(r, tracking.Hash())
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range remote {
+		if c.Hash == hash {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// reachable returns every commit reachable from the given one, newest first
+func reachable(r *git.Repository, from plumbing.Hash) ([]*object.Commit, error) {
+	commits := []*object.Commit{}
+
+	cIter, err := r.Log(&git.LogOptions{From: from, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cIter.ForEach(func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil && err != storer.ErrStop {
+		return nil, err
+	}
+
+	return commits, nil
+}
+
+// missingFrom returns the commits in a that do not appear in b
+func missingFrom(a []*object.Commit, b []*object.Commit) []*object.Commit {
+	seen := make(map[plumbing.Hash]bool, len(b))
+	for _, c := range b {
+		seen[c.Hash] = true
+	}
+
+	missing := []*object.Commit{}
+	for _, c := range a {
+		if !seen[c.Hash] {
+			missing = append(missing, c)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrNotRevertable is returned when asked to revert a root or merge commit
+var ErrNotRevertable = errors.New("only commits with exactly one parent can be reverted")
+
+// Revert puts every file the given commit changed back the way it was in the
+// commit's parent, and stages the result. It does not commit. It returns the
+// paths it touched, relative to the repository root
+func Revert(r *git.Repository, c *object.Commit) ([]string, error) {
+	if c.NumParents() != 1 {
+		return nil, ErrNotRevertable
+	}
+
+	parent, err := c.Parent(0)
+	if err != nil {
+		return nil, err
+	}
+
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := changedPaths(c)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse to clobber work that hasn't been committed yet
+	for _, path := range paths {
+		if fStatus, ok := status[path]; ok && (fStatus.Worktree != git.Unmodified || fStatus.Staging != git.Unmodified) {
+			return nil, fmt.Errorf("%s has uncommitted changes", path)
+		}
+	}
+
+	for _, path := range paths {
+		file, err := parentTree.File(path)
+
+		if err == object.ErrFileNotFound {
+			// The commit added this file, so reverting removes it
+			_, err = w.Remove(path)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = restoreFile(w, file)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = w.Add(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
+
+// restoreFile writes the contents of a file from the object store into the worktree
+func restoreFile(w *git.Worktree, file *object.File) error {
+	mode, err := file.Mode.ToOSFileMode()
+	if err != nil {
+		return err
+	}
+
+	rdr, err := file.Reader()
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	// The commit may have removed the file's directory along with it
+	err = w.Filesystem.MkdirAll(path.Dir(file.Name), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	out, err := w.Filesystem.OpenFile(file.Name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, rdr)
+
+	return err
+}
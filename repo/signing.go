@@ -0,0 +1,377 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SigningFormatOpenPGP signs commits with an armored OpenPGP private key
+	SigningFormatOpenPGP = "openpgp"
+
+	// SigningFormatSSH signs commits with an SSH private key
+	SigningFormatSSH = "ssh"
+
+	openPGPSigArmorStart = "-----BEGIN PGP SIGNATURE-----"
+
+	sshSigArmorStart = "-----BEGIN SSH SIGNATURE-----"
+	sshSigArmorEnd   = "-----END SSH SIGNATURE-----"
+	sshSigMagic      = "SSHSIG"
+	sshSigNamespace  = "git"
+	sshSigHashAlg    = "sha512"
+	sshSigVersion    = 1
+)
+
+var (
+	// ErrUnsigned is returned when verifying a commit that carries no signature
+	ErrUnsigned = errors.New("commit is not signed")
+
+	errNoPrivateKey     = errors.New("signing key file does not contain a private key")
+	errOpenPGPSigFormat = errors.New("not an OpenPGP signature")
+	errSigningFormat    = errors.New("unknown signing format, expected 'openpgp' or 'ssh'")
+	errSSHSigFormat     = errors.New("not a well-formed SSH signature")
+	errSSHSigNamespace  = errors.New("SSH signature was not made for git")
+	errSSHSigWrongKey   = errors.New("SSH signature was made by a different key")
+	errSSHSigHashAlg    = errors.New("SSH signature uses an unsupported hash algorithm")
+)
+
+// SigningKey signs commit payloads and verifies the signatures on them
+type SigningKey interface {
+	Sign(payload []byte) (string, error)
+	Verify(payload []byte, signature string) error
+}
+
+// LoadSigningKey reads the private key at keyPath and returns a SigningKey
+// for the given format. The passphrase is only used if the key is encrypted
+func LoadSigningKey(format string, keyPath string, passphrase string) (SigningKey, error) {
+	data, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case SigningFormatOpenPGP, "":
+		return newOpenPGPKey(data, passphrase)
+	case SigningFormatSSH:
+		return newSSHKey(data, passphrase)
+	default:
+		return nil, errSigningFormat
+	}
+}
+
+// SignCommit signs the commit identified by hash, stores the signed copy, and
+// moves HEAD to it. It returns the hash of the signed commit
+func SignCommit(r *git.Repository, hash plumbing.Hash, key SigningKey) (plumbing.Hash, error) {
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	payload, err := commitPayload(commit)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	sig, err := key.Sign(payload)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	commit.PGPSignature = sig
+
+	obj := r.Storer.NewEncodedObject()
+	err = commit.Encode(obj)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	signed, err := r.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return signed, updateHEAD(r, signed)
+}
+
+// VerifyCommit checks the signature on a commit against the given key
+func VerifyCommit(commit *object.Commit, key SigningKey) error {
+	if commit.PGPSignature == "" {
+		return ErrUnsigned
+	}
+
+	payload, err := commitPayload(commit)
+	if err != nil {
+		return err
+	}
+
+	return key.Verify(payload, commit.PGPSignature)
+}
+
+// commitPayload returns the bytes of the commit that the signature covers
+func commitPayload(commit *object.Commit) ([]byte, error) {
+	encoded := &plumbing.MemoryObject{}
+
+	err := commit.EncodeWithoutSignature(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	rdr, err := encoded.Reader()
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(rdr)
+}
+
+// updateHEAD points HEAD (or the branch HEAD refers to) at the given commit
+func updateHEAD(r *git.Repository, hash plumbing.Hash) error {
+	head, err := r.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return err
+	}
+
+	name := plumbing.HEAD
+	if head.Type() != plumbing.HashReference {
+		name = head.Target()
+	}
+
+	return r.Storer.SetReference(plumbing.NewHashReference(name, hash))
+}
+
+/* -------------------- OpenPGP -------------------- */
+
+type openPGPKey struct {
+	entity *openpgp.Entity
+}
+
+func newOpenPGPKey(data []byte, passphrase string) (*openPGPKey, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+
+		if entity.PrivateKey.Encrypted {
+			err := entity.PrivateKey.Decrypt([]byte(passphrase))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+				err := subkey.PrivateKey.Decrypt([]byte(passphrase))
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return &openPGPKey{entity: entity}, nil
+	}
+
+	return nil, errNoPrivateKey
+}
+
+func (key *openPGPKey) Sign(payload []byte) (string, error) {
+	var buf bytes.Buffer
+
+	err := openpgp.ArmoredDetachSign(&buf, key.entity, bytes.NewReader(payload), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func (key *openPGPKey) Verify(payload []byte, signature string) error {
+	if !strings.HasPrefix(signature, openPGPSigArmorStart) {
+		return errOpenPGPSigFormat
+	}
+
+	_, err := openpgp.CheckArmoredDetachedSignature(
+		openpgp.EntityList{key.entity},
+		bytes.NewReader(payload),
+		strings.NewReader(signature),
+	)
+
+	return err
+}
+
+/* -------------------- SSH -------------------- */
+
+// sshSigBlob is the body of an armored SSH signature, following the magic
+// preamble. See https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+type sshSigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is what actually gets signed, following the magic preamble
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+type sshKey struct {
+	signer ssh.Signer
+}
+
+func newSSHKey(data []byte, passphrase string) (*sshKey, error) {
+	var signer ssh.Signer
+	var err error
+
+	if passphrase == "" {
+		signer, err = ssh.ParsePrivateKey(data)
+	} else {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &sshKey{signer: signer}, nil
+}
+
+func (key *sshKey) Sign(payload []byte) (string, error) {
+	signed, err := sshSignedBytes(sshSigHashAlg, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var sig *ssh.Signature
+
+	// Plain ssh-rsa signatures use SHA-1, which git refuses, so ask for SHA-512
+	if algSigner, ok := key.signer.(ssh.AlgorithmSigner); ok && key.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = algSigner.SignWithAlgorithm(rand.Reader, signed, ssh.SigAlgoRSASHA2512)
+	} else {
+		sig, err = key.signer.Sign(rand.Reader, signed)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	blob := ssh.Marshal(sshSigBlob{
+		Version:       sshSigVersion,
+		PublicKey:     key.signer.PublicKey().Marshal(),
+		Namespace:     sshSigNamespace,
+		HashAlgorithm: sshSigHashAlg,
+		Signature:     ssh.Marshal(sig),
+	})
+
+	return armorSSHSig(append([]byte(sshSigMagic), blob...)), nil
+}
+
+func (key *sshKey) Verify(payload []byte, signature string) error {
+	raw, err := dearmorSSHSig(signature)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.HasPrefix(raw, []byte(sshSigMagic)) {
+		return errSSHSigFormat
+	}
+
+	blob := sshSigBlob{}
+	err = ssh.Unmarshal(raw[len(sshSigMagic):], &blob)
+	if err != nil {
+		return errSSHSigFormat
+	}
+
+	if blob.Namespace != sshSigNamespace {
+		return errSSHSigNamespace
+	}
+
+	if !bytes.Equal(blob.PublicKey, key.signer.PublicKey().Marshal()) {
+		return errSSHSigWrongKey
+	}
+
+	sig := ssh.Signature{}
+	err = ssh.Unmarshal(blob.Signature, &sig)
+	if err != nil {
+		return errSSHSigFormat
+	}
+
+	signed, err := sshSignedBytes(blob.HashAlgorithm, payload)
+	if err != nil {
+		return err
+	}
+
+	return key.signer.PublicKey().Verify(signed, &sig)
+}
+
+// sshSignedBytes builds the byte sequence that an SSH signature covers
+func sshSignedBytes(hashAlg string, payload []byte) ([]byte, error) {
+	var h hash.Hash
+
+	switch hashAlg {
+	case "sha512":
+		h = sha512.New()
+	case "sha256":
+		h = sha256.New()
+	default:
+		return nil, errSSHSigHashAlg
+	}
+
+	h.Write(payload)
+
+	data := ssh.Marshal(sshSignedData{
+		Namespace:     sshSigNamespace,
+		HashAlgorithm: hashAlg,
+		Hash:          h.Sum(nil),
+	})
+
+	return append([]byte(sshSigMagic), data...), nil
+}
+
+func armorSSHSig(raw []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(raw)
+
+	lines := []string{sshSigArmorStart}
+	for len(encoded) > 70 {
+		lines = append(lines, encoded[:70])
+		encoded = encoded[70:]
+	}
+	lines = append(lines, encoded, sshSigArmorEnd)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func dearmorSSHSig(armored string) ([]byte, error) {
+	armored = strings.TrimSpace(armored)
+
+	if !strings.HasPrefix(armored, sshSigArmorStart) || !strings.HasSuffix(armored, sshSigArmorEnd) {
+		return nil, errSSHSigFormat
+	}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(armored, sshSigArmorStart), sshSigArmorEnd)
+	body = strings.Join(strings.Fields(body), "")
+
+	return base64.StdEncoding.DecodeString(body)
+}
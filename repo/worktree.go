@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"github.com/go-git/go-git/v5"
+)
+
+// AddAll stages every change in the worktree. Worktree.Add only stages new
+// and modified files, so the files that have been deleted are removed from
+// the index separately
+func AddAll(w *git.Worktree) error {
+	_, err := w.Add(".")
+	if err != nil {
+		return err
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return err
+	}
+
+	for path, fStatus := range status {
+		if fStatus.Worktree != git.Deleted {
+			continue
+		}
+
+		_, err := w.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddChanged stages every change in the worktree except those to the skipped
+// paths, which are left as they are
+func AddChanged(w *git.Worktree, skip map[string]bool) error {
+	status, err := w.Status()
+	if err != nil {
+		return err
+	}
+
+	for path, fStatus := range status {
+		if skip[path] || fStatus.Worktree == git.Unmodified {
+			continue
+		}
+
+		if fStatus.Worktree == git.Deleted {
+			_, err = w.Remove(path)
+		} else {
+			_, err = w.Add(path)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UncommittedPaths returns the paths of the files in the worktree that have
+// changes, staged or not, that haven't been committed
+func UncommittedPaths(w *git.Worktree) (map[string]bool, error) {
+	status, err := w.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool)
+
+	for path, fStatus := range status {
+		if fStatus.Worktree != git.Unmodified || fStatus.Staging != git.Unmodified {
+			paths[path] = true
+		}
+	}
+
+	return paths, nil
+}
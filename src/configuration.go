@@ -83,6 +83,21 @@ func getConfigDir() (string, error) {
 	return cDir, nil
 }
 
+// ExpandPath turns a path that starts with a tilde into an absolute path
+// relative to the user's home directory. Other paths are returned as-is
+func ExpandPath(path string) (string, error) {
+	if path == "" || path[0] != '~' {
+		return path, nil
+	}
+
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New(errConfigExpandPath)
+	}
+
+	return filepath.Join(dir, path[1:]), nil
+}
+
 // GetConfigFilePath returns the string path to the configuration file
 func GetConfigFilePath() (string, error) {
 	cDir, err := getConfigDir()
@@ -0,0 +1,57 @@
+package src
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Matches a Markdown link, ie: [text](url)
+	mdLinkRegex = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)\)`)
+
+	// Matches bold text, ie: **text** or __text__
+	mdBoldRegex = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+
+	// Matches inline code, ie: `code`
+	mdCodeRegex = regexp.MustCompile("`([^`]+)`")
+
+	// Matches a bullet list item, ie: "* item" or "  - item"
+	mdBulletRegex = regexp.MustCompile(`^(\s*)[*+-]\s+`)
+)
+
+// RenderMarkdown makes Markdown easier to read in a terminal. Headings are
+// green, code is blue, links are shown as their text followed by their URL,
+// and the punctuation that only matters to Markdown is dropped
+func RenderMarkdown(content string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	rendered := []string{}
+	inCode := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			inCode = !inCode
+			continue
+		case inCode:
+			rendered = append(rendered, "    "+Blue(line))
+		case strings.HasPrefix(trimmed, "#"):
+			rendered = append(rendered, Green(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))))
+		default:
+			line = mdBulletRegex.ReplaceAllString(line, "$1• ")
+			line = mdLinkRegex.ReplaceAllStringFunc(line, func(link string) string {
+				parts := mdLinkRegex.FindStringSubmatch(link)
+				return parts[1] + " " + Blue("<"+parts[2]+">")
+			})
+			line = mdBoldRegex.ReplaceAllString(line, "$2")
+			line = mdCodeRegex.ReplaceAllStringFunc(line, func(code string) string {
+				return Blue(strings.Trim(code, "`"))
+			})
+
+			rendered = append(rendered, line)
+		}
+	}
+
+	return strings.Join(rendered, "\n") + "\n"
+}